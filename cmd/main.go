@@ -4,13 +4,18 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/critch-app/critch-backend/internal/adapters/primary/api"
+	"github.com/critch-app/critch-backend/internal/adapters/secondary/broker"
 	"github.com/critch-app/critch-backend/internal/adapters/secondary/database"
 	"github.com/critch-app/critch-backend/internal/application/application"
 	"github.com/critch-app/critch-backend/internal/application/core/entities"
 	"github.com/critch-app/critch-backend/internal/application/core/msgsrvc"
 	"github.com/critch-app/critch-backend/internal/ports"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 )
 
@@ -23,6 +28,20 @@ func main() {
 		DBPASS = os.Getenv("DB_PASS")
 		DBNAME = os.Getenv("DB_NAME")
 		DBPORT = os.Getenv("DB_PORT")
+
+		MESSAGING_BROKER             = os.Getenv("MESSAGING_BROKER")
+		MESSAGING_QUEUE_SIZE         = os.Getenv("MESSAGING_QUEUE_SIZE")
+		MESSAGING_SLOW_CLIENT_POLICY = os.Getenv("MESSAGING_SLOW_CLIENT_POLICY")
+		MESSAGING_EVENT_LOG_SIZE     = os.Getenv("MESSAGING_EVENT_LOG_SIZE")
+
+		WEBSOCKET_PING_INTERVAL    = os.Getenv("WEBSOCKET_PING_INTERVAL")
+		WEBSOCKET_PONG_TIMEOUT     = os.Getenv("WEBSOCKET_PONG_TIMEOUT")
+		WEBSOCKET_WRITE_TIMEOUT    = os.Getenv("WEBSOCKET_WRITE_TIMEOUT")
+		WEBSOCKET_MAX_MESSAGE_SIZE = os.Getenv("WEBSOCKET_MAX_MESSAGE_SIZE")
+		WEBSOCKET_RATE_LIMIT       = os.Getenv("WEBSOCKET_RATE_LIMIT")
+		WEBSOCKET_RATE_BURST       = os.Getenv("WEBSOCKET_RATE_BURST")
+
+		OPERATOR_IDS = os.Getenv("OPERATOR_IDS")
 	)
 
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=GMT",
@@ -30,6 +49,7 @@ func main() {
 
 	var (
 		dbAdapter        ports.DB
+		messageBroker    ports.Broker
 		app              application.AppI
 		messagingService *msgsrvc.MessagingService
 		server           ports.RESTAPI
@@ -46,24 +66,85 @@ func main() {
 		&entities.DMChannel{},
 		&entities.ServerChannel{},
 		&entities.User{},
+		&entities.UserPresence{},
 		&entities.DirectMessage{},
 		&entities.ServerMessage{},
 		&entities.ServerMember{},
 		&entities.DMChannelMember{},
 		&entities.ServerChannelMember{},
+		&entities.Session{},
+		&entities.ServerRole{},
+		&entities.ServerMemberRole{},
+		&entities.ChannelPermissionOverride{},
+		&entities.ServerInvite{},
+		&entities.ServerMessageReaction{},
+		&entities.DirectMessageReaction{},
+		&entities.ServerMessageRevision{},
+		&entities.DirectMessageRevision{},
+		&entities.ServerMessageMention{},
+		&entities.DirectMessageMention{},
+		&entities.ChannelReadState{},
 	)
 
 	if err != nil {
 		log.Fatalf("Migration Failed: %s", err)
 	}
 
-	messagingService = msgsrvc.NewService()
+	if MESSAGING_BROKER == "postgres" {
+		messageBroker, err = broker.NewPostgresBroker(dsn)
+		if err != nil {
+			log.Fatalf("Broker Connection Failed: %s", err)
+		}
+	} else {
+		messageBroker = broker.NewMemoryBroker()
+	}
+
+	queueSize, _ := strconv.Atoi(MESSAGING_QUEUE_SIZE)
+	eventLogSize, _ := strconv.Atoi(MESSAGING_EVENT_LOG_SIZE)
+
+	messagingService = msgsrvc.NewService(msgsrvc.Config{
+		Broker:           messageBroker,
+		QueueSize:        queueSize,
+		SlowClientPolicy: MESSAGING_SLOW_CLIENT_POLICY,
+		EventLogSize:     eventLogSize,
+	})
 
-	app = application.NewApp(dbAdapter, messagingService)
+	operatorIds := []uuid.UUID{}
+	for _, operatorId := range strings.Split(OPERATOR_IDS, ",") {
+		operatorId = strings.TrimSpace(operatorId)
+		if operatorId == "" {
+			continue
+		}
+
+		parsedId, err := uuid.Parse(operatorId)
+		if err != nil {
+			log.Fatalf("Invalid operator id %q: %s", operatorId, err)
+		}
+
+		operatorIds = append(operatorIds, parsedId)
+	}
+
+	app = application.NewApp(dbAdapter, messagingService, operatorIds)
 
 	go messagingService.Run()
+	go app.TrackPresence()
+	go app.TrackMemberships()
+
+	pingInterval, _ := time.ParseDuration(WEBSOCKET_PING_INTERVAL)
+	pongTimeout, _ := time.ParseDuration(WEBSOCKET_PONG_TIMEOUT)
+	writeTimeout, _ := time.ParseDuration(WEBSOCKET_WRITE_TIMEOUT)
+	maxMessageSize, _ := strconv.ParseInt(WEBSOCKET_MAX_MESSAGE_SIZE, 10, 64)
+	rateLimit, _ := strconv.ParseFloat(WEBSOCKET_RATE_LIMIT, 64)
+	rateBurst, _ := strconv.Atoi(WEBSOCKET_RATE_BURST)
 
-	server = api.NewAdapter(app)
+	server = api.NewAdapter(app, api.WebsocketConfig{
+		PingInterval:   pingInterval,
+		PongTimeout:    pongTimeout,
+		WriteTimeout:   writeTimeout,
+		MaxMessageSize: maxMessageSize,
+		RateLimit:      rateLimit,
+		RateBurst:      rateBurst,
+	})
 
 	err = server.Run()
 	if err != nil {
@@ -1,11 +1,17 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/critch-app/critch-backend/internal/application/application"
 	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/application/core/msgsrvc"
+	"github.com/critch-app/critch-backend/internal/ports"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -19,18 +25,65 @@ func (api *Adapter) login(ctx *gin.Context) {
 		return
 	}
 
-	token, userId, err := api.app.Login(credentials.Email, credentials.Password)
+	token, refreshToken, userId, err := api.app.Login(credentials.Email, credentials.Password)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{
-		"token":   token,
-		"user_id": userId,
+		"token":         token,
+		"refresh_token": refreshToken,
+		"user_id":       userId,
 	})
 }
 
+func (api *Adapter) refreshToken(ctx *gin.Context) {
+	request := &refreshTokenRequest{}
+
+	err := ctx.ShouldBindJSON(request)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	token, refreshToken, userId, err := api.app.RefreshToken(request.RefreshToken)
+	if err != nil {
+		reportError(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"token":         token,
+		"refresh_token": refreshToken,
+		"user_id":       userId,
+	})
+}
+
+func (api *Adapter) logout(ctx *gin.Context) {
+	sessionId, _ := ctx.Get("session_id")
+
+	err := api.app.Logout(sessionId.(uuid.UUID))
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
+func (api *Adapter) logoutAllDevices(ctx *gin.Context) {
+	userId, _ := ctx.Get("user_id")
+
+	err := api.app.LogoutAllDevices(userId.(uuid.UUID))
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
 func (api *Adapter) signup(ctx *gin.Context) {
 	user := &entities.User{}
 
@@ -89,6 +142,12 @@ func (api *Adapter) deleteUser(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeUser(getActorId(ctx), userId)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	err = api.app.DeleteUser(userId)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
@@ -105,6 +164,12 @@ func (api *Adapter) updateUser(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeUser(getActorId(ctx), userId)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	user := &entities.User{}
 
 	err = ctx.ShouldBindJSON(user)
@@ -131,6 +196,12 @@ func (api *Adapter) getUserServers(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeUser(getActorId(ctx), userId)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	offset, limit := getPagination(ctx)
 
 	servers, err := api.app.GetUserServers(userId, offset, limit)
@@ -144,6 +215,14 @@ func (api *Adapter) getUserServers(ctx *gin.Context) {
 		serversData[idx] = getResponseServer(&server)
 	}
 
+	unreadCounts, err := api.app.GetServerUnreadCounts(userId, getResponseIds(serversData))
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	attachUnreadCounts(serversData, unreadCounts)
+
 	ctx.JSON(http.StatusOK, serversData)
 }
 
@@ -154,6 +233,12 @@ func (api *Adapter) getUserChannels(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeUser(getActorId(ctx), userId)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	offset, limit := getPagination(ctx)
 
 	channels, err := api.app.GetUserDMChannels(userId, offset, limit)
@@ -167,13 +252,21 @@ func (api *Adapter) getUserChannels(ctx *gin.Context) {
 		channelsData[idx] = getResponseChannel(&channel, false)
 	}
 
+	unreadCounts, err := api.app.GetUnreadCounts(userId, getResponseIds(channelsData), false)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	attachUnreadCounts(channelsData, unreadCounts)
+
 	ctx.JSON(http.StatusOK, channelsData)
 }
 
 func (api *Adapter) getAllServers(ctx *gin.Context) {
 	offset, limit := getPagination(ctx)
 
-	servers, err := api.app.GetAllServers(offset, limit)
+	servers, err := api.app.GetAllServers(getActorId(ctx), offset, limit)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
 		return
@@ -202,6 +295,12 @@ func (api *Adapter) createServer(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeUser(getActorId(ctx), OwnerID)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	server := &entities.Server{
 		Name:        serverRequest.Name,
 		Description: serverRequest.Description,
@@ -224,6 +323,12 @@ func (api *Adapter) getServer(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.VIEW_SERVER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	server, err := api.app.GetServer(serverId)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
@@ -240,6 +345,12 @@ func (api *Adapter) deleteServer(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.DELETE_SERVER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	err = api.app.DeleteServer(serverId)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
@@ -256,6 +367,12 @@ func (api *Adapter) updateServer(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.MANAGE_SERVER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	server := &entities.Server{}
 
 	err = ctx.ShouldBindJSON(server)
@@ -282,6 +399,12 @@ func (api *Adapter) getServerMembers(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.VIEW_SERVER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	offset, limit := getPagination(ctx)
 
 	members, err := api.app.GetServerMembers(serverId, offset, limit)
@@ -311,6 +434,12 @@ func (api *Adapter) addServerMember(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.MANAGE_MEMBERS)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	err = api.app.AddServerMember(serverId, userId)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
@@ -333,6 +462,12 @@ func (api *Adapter) removeServerMember(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeServerMemberRemoval(getActorId(ctx), serverId, userId)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	err = api.app.RemoveServerMember(serverId, userId)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
@@ -342,6 +477,157 @@ func (api *Adapter) removeServerMember(ctx *gin.Context) {
 	ctx.JSON(http.StatusNoContent, gin.H{})
 }
 
+func (api *Adapter) createInvite(ctx *gin.Context) {
+	serverId, err := uuid.Parse(ctx.Param("server-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	inviteRequest := &inviteRequest{}
+
+	err = ctx.ShouldBindJSON(inviteRequest)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	actorId := getActorId(ctx)
+
+	err = api.app.AuthorizeServer(actorId, serverId, application.CREATE_INVITE)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	invite := &entities.ServerInvite{
+		ServerID:  serverId,
+		CreatorID: actorId,
+		MaxUses:   inviteRequest.MaxUses,
+	}
+
+	if inviteRequest.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(inviteRequest.ExpiresIn) * time.Second)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	if inviteRequest.RoleID != "" {
+		roleId, err := uuid.Parse(inviteRequest.RoleID)
+		if err != nil {
+			reportError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		err = api.app.AuthorizeRole(actorId, &entities.ServerRole{ID: roleId, ServerID: serverId})
+		if err != nil {
+			reportAuthorizationError(ctx, err)
+			return
+		}
+
+		invite.RoleID = &roleId
+	}
+
+	err = api.app.CreateInvite(invite)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, getResponseInvite(invite))
+}
+
+func (api *Adapter) getServerInvites(ctx *gin.Context) {
+	serverId, err := uuid.Parse(ctx.Param("server-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.MANAGE_SERVER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	offset, limit := getPagination(ctx)
+
+	invites, err := api.app.GetServerInvites(serverId, offset, limit)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	invitesData := make([]gin.H, len(*invites))
+	for idx, invite := range *invites {
+		invitesData[idx] = getResponseInvite(&invite)
+	}
+
+	ctx.JSON(http.StatusOK, invitesData)
+}
+
+func (api *Adapter) deleteInvite(ctx *gin.Context) {
+	serverId, err := uuid.Parse(ctx.Param("server-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.MANAGE_SERVER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	invite, err := api.app.GetInvite(ctx.Param("code"))
+	if err != nil || invite.ServerID != serverId {
+		reportError(ctx, http.StatusNotFound, application.ErrInvalidInvite)
+		return
+	}
+
+	err = api.app.DeleteInvite(invite.Code)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
+func (api *Adapter) getInvite(ctx *gin.Context) {
+	invite, err := api.app.GetInvite(ctx.Param("code"))
+	if err != nil {
+		reportError(ctx, http.StatusNotFound, application.ErrInvalidInvite)
+		return
+	}
+
+	server, err := api.app.GetServer(invite.ServerID)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":       invite.Code,
+		"server":     getResponseServer(server),
+		"expires_at": invite.ExpiresAt,
+	})
+}
+
+func (api *Adapter) redeemInvite(ctx *gin.Context) {
+	server, err := api.app.RedeemInvite(ctx.Param("code"), getActorId(ctx))
+	if errors.Is(err, application.ErrInvalidInvite) {
+		reportError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getResponseServer(server))
+}
+
 func (api *Adapter) getServerChannels(ctx *gin.Context) {
 	serverId, err := uuid.Parse(ctx.Param("server-id"))
 	if err != nil {
@@ -349,6 +635,12 @@ func (api *Adapter) getServerChannels(ctx *gin.Context) {
 		return
 	}
 
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.VIEW_SERVER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	offset, limit := getPagination(ctx)
 
 	userId, _ := ctx.Get("user_id")
@@ -364,6 +656,14 @@ func (api *Adapter) getServerChannels(ctx *gin.Context) {
 		channelsData[idx] = getResponseChannel(&channel, true)
 	}
 
+	unreadCounts, err := api.app.GetUnreadCounts(userId.(uuid.UUID), getResponseIds(channelsData), true)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	attachUnreadCounts(channelsData, unreadCounts)
+
 	ctx.JSON(http.StatusOK, channelsData)
 }
 
@@ -378,7 +678,7 @@ func (api *Adapter) getAllChannels(ctx *gin.Context) {
 		channels = &[]entities.DMChannel{}
 	}
 
-	err := api.app.GetAllChannels(channels, offset, limit)
+	err := api.app.GetAllChannels(channels, getActorId(ctx), offset, limit)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
 		return
@@ -410,6 +710,14 @@ func (api *Adapter) createChannel(ctx *gin.Context) {
 		return
 	}
 
+	if isServerChannel {
+		err = api.app.AuthorizeServer(getActorId(ctx), channel.(*entities.ServerChannel).ServerID, application.MANAGE_CHANNELS)
+		if err != nil {
+			reportAuthorizationError(ctx, err)
+			return
+		}
+	}
+
 	userId, _ := ctx.Get("user_id")
 
 	err = api.app.CreateChannel(channel, userId.(uuid.UUID), isServerChannel)
@@ -429,6 +737,13 @@ func (api *Adapter) getChannel(ctx *gin.Context) {
 	}
 
 	_, isServerChannel := ctx.GetQuery("isServerChannel")
+
+	err = api.app.AuthorizeChannel(getActorId(ctx), channelId, isServerChannel, application.VIEW_CHANNEL)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	var channel any
 	if isServerChannel {
 		channel = &entities.ServerChannel{Channel: entities.Channel{ID: channelId}}
@@ -453,6 +768,13 @@ func (api *Adapter) deleteChannel(ctx *gin.Context) {
 	}
 
 	_, isServerChannel := ctx.GetQuery("isServerChannel")
+
+	err = api.app.AuthorizeChannel(getActorId(ctx), channelId, isServerChannel, application.MANAGE_CHANNEL)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	var channel any
 	if isServerChannel {
 		channel = &entities.ServerChannel{Channel: entities.Channel{ID: channelId}}
@@ -477,6 +799,13 @@ func (api *Adapter) updateChannel(ctx *gin.Context) {
 	}
 
 	_, isServerChannel := ctx.GetQuery("isServerChannel")
+
+	err = api.app.AuthorizeChannel(getActorId(ctx), channelId, isServerChannel, application.MANAGE_CHANNEL)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	var channel any
 	if isServerChannel {
 		channelModel := &entities.ServerChannel{}
@@ -514,6 +843,13 @@ func (api *Adapter) getChannelMembers(ctx *gin.Context) {
 	offset, limit := getPagination(ctx)
 
 	_, isServerChannel := ctx.GetQuery("isServerChannel")
+
+	err = api.app.AuthorizeChannel(getActorId(ctx), channelId, isServerChannel, application.VIEW_CHANNEL)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
 	var channelMembers any
 	if isServerChannel {
 		channelMembers = &[]entities.ServerChannelMember{}
@@ -545,26 +881,16 @@ func (api *Adapter) addChannelMember(ctx *gin.Context) {
 		return
 	}
 
-	serverId, exists := ctx.GetQuery("serverId")
-
-	var channelMember any
-	if exists {
-		uServerId, err := uuid.Parse(serverId)
-		if err != nil {
-			reportError(ctx, http.StatusBadRequest, err)
-			return
-		}
+	channelMember, isServerChannel, err := api.getChannelMember(ctx, channelId, userId)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
 
-		channelMember = &entities.ServerChannelMember{
-			ChannelID: channelId,
-			ServerID:  uServerId,
-			UserID:    userId,
-		}
-	} else {
-		channelMember = &entities.DMChannelMember{
-			ChannelID: channelId,
-			UserID:    userId,
-		}
+	err = api.app.AuthorizeChannelMember(getActorId(ctx), channelId, userId, isServerChannel, application.ADD_MEMBER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
 	}
 
 	err = api.app.AddChannelMember(channelMember)
@@ -589,170 +915,935 @@ func (api *Adapter) removeChannelMember(ctx *gin.Context) {
 		return
 	}
 
-	serverId, exists := ctx.GetQuery("serverId")
+	channelMember, isServerChannel, err := api.getChannelMember(ctx, channelId, userId)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
 
-	var channelMember any
-	if exists {
-		uServerId, err := uuid.Parse(serverId)
-		if err != nil {
-			reportError(ctx, http.StatusBadRequest, err)
-			return
-		}
+	err = api.app.AuthorizeChannelMember(getActorId(ctx), channelId, userId, isServerChannel, application.REMOVE_MEMBER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
 
-		channelMember = &entities.ServerChannelMember{
-			ChannelID: channelId,
-			ServerID:  uServerId,
-			UserID:    userId,
-		}
+	err = api.app.RemoveChannelMember(channelMember)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
+func (api *Adapter) getChannelOverrides(ctx *gin.Context) {
+	channelId, err := uuid.Parse(ctx.Param("channel-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = api.app.AuthorizeChannel(getActorId(ctx), channelId, true, application.MANAGE_CHANNEL)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	overrides, err := api.app.GetChannelOverrides(channelId)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	overridesData := make([]gin.H, len(*overrides))
+	for idx, override := range *overrides {
+		overridesData[idx] = getResponseOverride(&override)
+	}
+
+	ctx.JSON(http.StatusOK, overridesData)
+}
+
+func (api *Adapter) setChannelOverride(ctx *gin.Context) {
+	channelId, err := uuid.Parse(ctx.Param("channel-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	targetId, err := uuid.Parse(ctx.Param("target-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	targetType := ctx.Param("target-type")
+	if targetType != entities.ROLE_OVERRIDE && targetType != entities.USER_OVERRIDE {
+		reportError(ctx, http.StatusBadRequest, errors.New("target type must be role or user"))
+		return
+	}
+
+	overrideRequest := &overrideRequest{}
+
+	err = ctx.ShouldBindJSON(overrideRequest)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	allow, err := entities.ParsePermissions(overrideRequest.Allow)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	deny, err := entities.ParsePermissions(overrideRequest.Deny)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = api.app.AuthorizeChannel(getActorId(ctx), channelId, true, application.MANAGE_CHANNEL)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	override := &entities.ChannelPermissionOverride{
+		ChannelID:  channelId,
+		TargetType: targetType,
+		TargetID:   targetId,
+		Allow:      allow,
+		Deny:       deny &^ allow,
+	}
+
+	err = api.app.SetChannelOverride(override)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getResponseOverride(override))
+}
+
+func (api *Adapter) removeChannelOverride(ctx *gin.Context) {
+	channelId, err := uuid.Parse(ctx.Param("channel-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	targetId, err := uuid.Parse(ctx.Param("target-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = api.app.AuthorizeChannel(getActorId(ctx), channelId, true, application.MANAGE_CHANNEL)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	err = api.app.RemoveChannelOverride(channelId, targetId)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
+func (api *Adapter) getChannelMessages(ctx *gin.Context) {
+	channelId, err := uuid.Parse(ctx.Param("channel-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	_, limit := getPagination(ctx)
+
+	cursor, err := getMessageCursor(ctx)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	_, isServerChannel := ctx.GetQuery("isServerChannel")
+
+	err = api.app.AuthorizeChannel(getActorId(ctx), channelId, isServerChannel, application.VIEW_CHANNEL)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	var channelMessages any
+	if isServerChannel {
+		channelMessages = &[]entities.ServerMessage{}
+	} else {
+		channelMessages = &[]entities.DirectMessage{}
+	}
+
+	err = api.app.GetChannelMessages(channelMessages, channelId, cursor, limit)
+	if errors.Is(err, ports.ErrNotFound) {
+		reportError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	membersData := getResponseMessageArray(channelMessages, isServerChannel)
+
+	err = api.attachReactions(membersData, isServerChannel)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, membersData)
+}
+
+func (api *Adapter) getMessage(ctx *gin.Context) {
+	messageId, err := uuid.Parse(ctx.Param("message-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	_, isServerMessage := ctx.GetQuery("isServerMessage")
+
+	err = api.app.AuthorizeMessage(getActorId(ctx), messageId, isServerMessage, application.VIEW_MESSAGE)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	var message any
+	if isServerMessage {
+		message = &entities.ServerMessage{Message: entities.Message{ID: messageId}}
+	} else {
+		message = &entities.DirectMessage{Message: entities.Message{ID: messageId}}
+	}
+
+	err = api.app.GetMessage(message)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	messageData := getResponseMessage(message, isServerMessage)
+
+	err = api.attachReactions([]gin.H{messageData}, isServerMessage)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, messageData)
+}
+
+func (api *Adapter) searchMessages(ctx *gin.Context) {
+	searchRequest := &searchMessagesRequest{}
+
+	err := ctx.ShouldBindQuery(searchRequest)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	offset, limit := getPagination(ctx)
+
+	search := &entities.MessageSearch{
+		Query:         searchRequest.Query,
+		UserID:        getActorId(ctx),
+		From:          searchRequest.From,
+		To:            searchRequest.To,
+		HasAttachment: searchRequest.HasAttachment,
+		MentionsMe:    searchRequest.MentionsMe,
+		Offset:        offset,
+		Limit:         limit,
+	}
+
+	search.SenderID, err = parseOptionalId(searchRequest.SenderID)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	search.ChannelID, err = parseOptionalId(searchRequest.ChannelID)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	search.ServerID, err = parseOptionalId(searchRequest.ServerID)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := api.app.SearchMessages(search)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	resultsData := make([]gin.H, len(*results))
+	for idx, result := range *results {
+		resultsData[idx] = getResponseSearchResult(&result)
+	}
+
+	ctx.JSON(http.StatusOK, resultsData)
+}
+
+func (api *Adapter) getMessageReplies(ctx *gin.Context) {
+	messageId, err := uuid.Parse(ctx.Param("message-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	_, isServerMessage := ctx.GetQuery("isServerMessage")
+
+	err = api.app.AuthorizeMessage(getActorId(ctx), messageId, isServerMessage, application.VIEW_MESSAGE)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	offset, limit := getPagination(ctx)
+
+	var replies any
+	if isServerMessage {
+		replies = &[]entities.ServerMessage{}
+	} else {
+		replies = &[]entities.DirectMessage{}
+	}
+
+	err = api.app.GetMessageReplies(replies, messageId, offset, limit)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	repliesData := getResponseMessageArray(replies, isServerMessage)
+
+	err = api.attachReactions(repliesData, isServerMessage)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, repliesData)
+}
+
+func (api *Adapter) getMessageHistory(ctx *gin.Context) {
+	messageId, err := uuid.Parse(ctx.Param("message-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	_, isServerMessage := ctx.GetQuery("isServerMessage")
+
+	err = api.app.AuthorizeMessage(getActorId(ctx), messageId, isServerMessage, application.VIEW_MESSAGE)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	offset, limit := getPagination(ctx)
+
+	var revisions any
+	if isServerMessage {
+		revisions = &[]entities.ServerMessageRevision{}
+	} else {
+		revisions = &[]entities.DirectMessageRevision{}
+	}
+
+	err = api.app.GetMessageRevisions(revisions, messageId, offset, limit)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getResponseRevisionArray(revisions, isServerMessage))
+}
+
+func (api *Adapter) addReaction(ctx *gin.Context) {
+	api.updateReaction(ctx, true)
+}
+
+func (api *Adapter) removeReaction(ctx *gin.Context) {
+	api.updateReaction(ctx, false)
+}
+
+func (api *Adapter) updateReaction(ctx *gin.Context, isAdded bool) {
+	messageId, err := uuid.Parse(ctx.Param("message-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	emoji := ctx.Param("emoji")
+	if emoji == "" || len(emoji) > MAX_EMOJI_LENGTH {
+		reportError(ctx, http.StatusBadRequest, errors.New("invalid emoji"))
+		return
+	}
+
+	_, isServerMessage := ctx.GetQuery("isServerMessage")
+
+	actorId := getActorId(ctx)
+
+	err = api.app.AuthorizeMessage(actorId, messageId, isServerMessage, application.VIEW_MESSAGE)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	if isAdded {
+		err = api.app.AddReaction(messageId, actorId, emoji, isServerMessage)
 	} else {
-		channelMember = &entities.DMChannelMember{
-			ChannelID: channelId,
-			UserID:    userId,
+		err = api.app.RemoveReaction(messageId, actorId, emoji, isServerMessage)
+	}
+
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
+func (api *Adapter) markChannelRead(ctx *gin.Context) {
+	channelId, err := uuid.Parse(ctx.Param("channel-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	markReadRequest := &markReadRequest{}
+
+	err = ctx.ShouldBindJSON(markReadRequest)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	messageId, err := uuid.Parse(markReadRequest.MessageID)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	_, isServerChannel := ctx.GetQuery("isServerChannel")
+
+	actorId := getActorId(ctx)
+
+	err = api.app.AuthorizeChannel(actorId, channelId, isServerChannel, application.VIEW_CHANNEL)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	err = api.app.MarkRead(&msgsrvc.MarkRead{
+		ChannelId: channelId,
+		MessageId: messageId,
+		SenderId:  actorId,
+	}, isServerChannel)
+
+	if errors.Is(err, application.ErrValidation) {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
+func getResponseIds(data []gin.H) []uuid.UUID {
+	ids := make([]uuid.UUID, len(data))
+	for idx, obj := range data {
+		ids[idx] = obj["id"].(uuid.UUID)
+	}
+
+	return ids
+}
+
+func attachUnreadCounts(data []gin.H, unreadCounts *[]entities.UnreadCount) {
+	counts := make(map[uuid.UUID]entities.UnreadCount)
+	for _, unreadCount := range *unreadCounts {
+		counts[unreadCount.ID] = unreadCount
+	}
+
+	for _, obj := range data {
+		count := counts[obj["id"].(uuid.UUID)]
+		obj["unread_count"] = count.UnreadCount
+		obj["mention_count"] = count.MentionCount
+	}
+}
+
+func (api *Adapter) attachReactions(messagesData []gin.H, isServerMessage bool) error {
+	messageIds := make([]uuid.UUID, len(messagesData))
+	for idx, messageData := range messagesData {
+		messageIds[idx] = messageData["id"].(uuid.UUID)
+	}
+
+	reactionCounts, err := api.app.GetReactionCounts(messageIds, isServerMessage)
+	if err != nil {
+		return err
+	}
+
+	reactions := make(map[uuid.UUID][]gin.H)
+	for _, reactionCount := range *reactionCounts {
+		reactions[reactionCount.MessageID] = append(reactions[reactionCount.MessageID], gin.H{
+			"emoji": reactionCount.Emoji,
+			"count": reactionCount.Count,
+		})
+	}
+
+	for _, messageData := range messagesData {
+		messageReactions := reactions[messageData["id"].(uuid.UUID)]
+		if messageReactions == nil {
+			messageReactions = []gin.H{}
 		}
+
+		messageData["reactions"] = messageReactions
+	}
+
+	return nil
+}
+
+func (api *Adapter) deleteMessage(ctx *gin.Context) {
+	messageId, err := uuid.Parse(ctx.Param("message-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	_, isServerMessage := ctx.GetQuery("isServerMessage")
+
+	err = api.app.AuthorizeMessage(getActorId(ctx), messageId, isServerMessage, application.DELETE_MESSAGE)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	var message any
+	if isServerMessage {
+		message = &entities.ServerMessage{Message: entities.Message{ID: messageId}}
+	} else {
+		message = &entities.DirectMessage{Message: entities.Message{ID: messageId}}
+	}
+
+	err = api.app.DeleteMessage(message)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
+func (api *Adapter) updateMessage(ctx *gin.Context) {
+	messageId, err := uuid.Parse(ctx.Param("message-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	_, isServerMessage := ctx.GetQuery("isServerMessage")
+
+	err = api.app.AuthorizeMessage(getActorId(ctx), messageId, isServerMessage, application.EDIT_MESSAGE)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	var message any
+	if isServerMessage {
+		messageModel := &entities.ServerMessage{}
+		err = ctx.ShouldBindJSON(messageModel)
+		messageModel.ID = messageId
+		message = messageModel
+	} else {
+		messageModel := &entities.DirectMessage{}
+		err = ctx.ShouldBindJSON(messageModel)
+		messageModel.ID = messageId
+		message = messageModel
+	}
+
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = api.app.UpdateMessage(message)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
 	}
 
-	err = api.app.RemoveChannelMember(channelMember)
+	ctx.JSON(http.StatusOK, getResponseMessage(message, isServerMessage))
+}
+
+func (api *Adapter) getMessagingStats(ctx *gin.Context) {
+	err := api.app.AuthorizeOperator(getActorId(ctx))
 	if err != nil {
-		reportError(ctx, http.StatusInternalServerError, err)
+		reportAuthorizationError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusNoContent, gin.H{})
+	ctx.JSON(http.StatusOK, api.app.GetMessagingStats())
 }
 
-func (api *Adapter) getChannelMessages(ctx *gin.Context) {
-	channelId, err := uuid.Parse(ctx.Param("channel-id"))
+func (api *Adapter) getServerMemberRole(ctx *gin.Context) {
+	serverId, err := uuid.Parse(ctx.Query("serverId"))
 	if err != nil {
 		reportError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	offset, limit := getPagination(ctx)
+	userId, err := uuid.Parse(ctx.Query("userId"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
 
-	_, isServerChannel := ctx.GetQuery("isServerChannel")
-	var channelMessages any
-	if isServerChannel {
-		channelMessages = &[]entities.ServerMessage{}
-	} else {
-		channelMessages = &[]entities.DirectMessage{}
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.VIEW_SERVER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	role, permissions, err := api.app.GetServerMemberPermissions(serverId, userId)
+	if err != nil {
+		reportError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"role":        role,
+		"permissions": permissions.Names(),
+	})
+}
+
+func (api *Adapter) getServerRoles(ctx *gin.Context) {
+	serverId, err := uuid.Parse(ctx.Param("server-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.VIEW_SERVER)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
 	}
 
-	err = api.app.GetChannelMessages(channelMessages, channelId, offset, limit)
+	offset, limit := getPagination(ctx)
+
+	roles, err := api.app.GetServerRoles(serverId, offset, limit)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	membersData := getResponseMessageArray(channelMessages, isServerChannel)
+	rolesData := make([]gin.H, len(*roles))
+	for idx, role := range *roles {
+		rolesData[idx] = getResponseRole(&role)
+	}
 
-	ctx.JSON(http.StatusOK, membersData)
+	ctx.JSON(http.StatusOK, rolesData)
 }
 
-func (api *Adapter) getMessage(ctx *gin.Context) {
-	messageId, err := uuid.Parse(ctx.Param("message-id"))
+func (api *Adapter) createRole(ctx *gin.Context) {
+	serverId, err := uuid.Parse(ctx.Param("server-id"))
 	if err != nil {
 		reportError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	_, isServerMessage := ctx.GetQuery("isServerMessage")
-	var message any
-	if isServerMessage {
-		message = &entities.ServerMessage{Message: entities.Message{ID: messageId}}
-	} else {
-		message = &entities.DirectMessage{Message: entities.Message{ID: messageId}}
+	role, err := bindRoleRequest(ctx)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
 	}
 
-	err = api.app.GetMessage(message)
+	role.ServerID = serverId
+
+	err = api.app.AuthorizeRole(getActorId(ctx), role)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	err = api.app.CreateRole(role)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, getResponseMessage(message, isServerMessage))
+	ctx.JSON(http.StatusCreated, getResponseRole(role))
 }
 
-func (api *Adapter) deleteMessage(ctx *gin.Context) {
-	messageId, err := uuid.Parse(ctx.Param("message-id"))
+func (api *Adapter) getRole(ctx *gin.Context) {
+	serverId, err := uuid.Parse(ctx.Param("server-id"))
 	if err != nil {
 		reportError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	_, isServerMessage := ctx.GetQuery("isServerMessage")
-	var message any
-	if isServerMessage {
-		message = &entities.ServerMessage{Message: entities.Message{ID: messageId}}
-	} else {
-		message = &entities.DirectMessage{Message: entities.Message{ID: messageId}}
+	roleId, err := uuid.Parse(ctx.Param("role-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
 	}
 
-	err = api.app.DeleteMessage(message)
+	err = api.app.AuthorizeServer(getActorId(ctx), serverId, application.VIEW_SERVER)
 	if err != nil {
-		reportError(ctx, http.StatusInternalServerError, err)
+		reportAuthorizationError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusNoContent, gin.H{})
+	role, err := api.app.GetRole(roleId)
+	if err != nil || role.ServerID != serverId {
+		reportError(ctx, http.StatusNotFound, errors.New("role not found"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getResponseRole(role))
 }
 
-func (api *Adapter) updateMessage(ctx *gin.Context) {
-	messageId, err := uuid.Parse(ctx.Param("message-id"))
+func (api *Adapter) updateRole(ctx *gin.Context) {
+	serverId, err := uuid.Parse(ctx.Param("server-id"))
 	if err != nil {
 		reportError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	_, isServerMessage := ctx.GetQuery("isServerMessage")
-	var message any
-	if isServerMessage {
-		messageModel := &entities.ServerMessage{}
-		err = ctx.ShouldBindJSON(messageModel)
-		messageModel.ID = messageId
-		message = messageModel
-	} else {
-		messageModel := &entities.DirectMessage{}
-		err = ctx.ShouldBindJSON(messageModel)
-		messageModel.ID = messageId
-		message = messageModel
+	roleId, err := uuid.Parse(ctx.Param("role-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
 	}
 
+	role, err := bindRoleRequest(ctx)
 	if err != nil {
 		reportError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	err = api.app.UpdateMessage(message)
+	role.ID = roleId
+	role.ServerID = serverId
+
+	err = api.app.AuthorizeRole(getActorId(ctx), role)
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	err = api.app.UpdateRole(role)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, getResponseMessage(message, isServerMessage))
+	ctx.JSON(http.StatusOK, getResponseRole(role))
 }
 
-func (api *Adapter) getServerMemberRole(ctx *gin.Context) {
-	serverId, err := uuid.Parse(ctx.Query("serverId"))
+func (api *Adapter) deleteRole(ctx *gin.Context) {
+	serverId, err := uuid.Parse(ctx.Param("server-id"))
 	if err != nil {
 		reportError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	userId, err := uuid.Parse(ctx.Query("userId"))
+	roleId, err := uuid.Parse(ctx.Param("role-id"))
 	if err != nil {
 		reportError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	role, err := api.app.GetServerMemberRole(serverId, userId)
+	err = api.app.AuthorizeRole(getActorId(ctx), &entities.ServerRole{ID: roleId, ServerID: serverId})
 	if err != nil {
-		reportError(ctx, http.StatusNotFound, err)
+		reportAuthorizationError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"role": role,
-	})
+	err = api.app.DeleteRole(roleId)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
+func (api *Adapter) addMemberRole(ctx *gin.Context) {
+	serverId, userId, roleId, err := getMemberRoleParams(ctx)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = api.app.AuthorizeRole(getActorId(ctx), &entities.ServerRole{ID: roleId, ServerID: serverId})
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	err = api.app.AddMemberRole(serverId, userId, roleId)
+	if errors.Is(err, ports.ErrConflict) {
+		reportError(ctx, http.StatusConflict, err)
+		return
+	}
+
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
+func (api *Adapter) removeMemberRole(ctx *gin.Context) {
+	serverId, userId, roleId, err := getMemberRoleParams(ctx)
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = api.app.AuthorizeRole(getActorId(ctx), &entities.ServerRole{ID: roleId, ServerID: serverId})
+	if err != nil {
+		reportAuthorizationError(ctx, err)
+		return
+	}
+
+	err = api.app.RemoveMemberRole(serverId, userId, roleId)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, gin.H{})
+}
+
+func bindRoleRequest(ctx *gin.Context) (*entities.ServerRole, error) {
+	roleRequest := &roleRequest{}
+
+	err := ctx.ShouldBindJSON(roleRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := entities.ParsePermissions(roleRequest.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if roleRequest.Position < 1 {
+		roleRequest.Position = 1
+	}
+
+	return &entities.ServerRole{
+		Name:        roleRequest.Name,
+		Permissions: permissions,
+		Position:    roleRequest.Position,
+	}, nil
+}
+
+func getMemberRoleParams(ctx *gin.Context) (serverId, userId, roleId uuid.UUID, err error) {
+	serverId, err = uuid.Parse(ctx.Param("server-id"))
+	if err != nil {
+		return
+	}
+
+	userId, err = uuid.Parse(ctx.Param("user-id"))
+	if err != nil {
+		return
+	}
+
+	roleId, err = uuid.Parse(ctx.Param("role-id"))
+	return
+}
+
+const MAX_EMOJI_LENGTH = 64
+
+func (api *Adapter) getChannelMember(ctx *gin.Context, channelId, userId uuid.UUID) (any, bool, error) {
+	serverId, exists := ctx.GetQuery("serverId")
+	if !exists {
+		return &entities.DMChannelMember{ChannelID: channelId, UserID: userId}, false, nil
+	}
+
+	uServerId, err := uuid.Parse(serverId)
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %w", application.ErrValidation, err)
+	}
+
+	channel := &entities.ServerChannel{Channel: entities.Channel{ID: channelId}}
+	err = api.app.GetChannel(channel)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if channel.ServerID != uServerId {
+		return nil, true, fmt.Errorf("%w: channel does not belong to the server", application.ErrValidation)
+	}
+
+	return &entities.ServerChannelMember{
+		ChannelID: channelId,
+		ServerID:  channel.ServerID,
+		UserID:    userId,
+	}, true, nil
+}
+
+func getActorId(ctx *gin.Context) uuid.UUID {
+	actorId, _ := ctx.Get("user_id")
+	return actorId.(uuid.UUID)
+}
+
+func parseOptionalId(id string) (*uuid.UUID, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsedId, nil
+}
+
+func getMessageCursor(ctx *gin.Context) (*entities.MessageCursor, error) {
+	var cursor *entities.MessageCursor
+
+	for _, direction := range []string{entities.BEFORE_CURSOR, entities.AFTER_CURSOR, entities.AROUND_CURSOR} {
+		messageIdQuery, exists := ctx.GetQuery(direction)
+		if !exists {
+			continue
+		}
+
+		if cursor != nil {
+			return nil, errors.New("only one of before, after or around can be set")
+		}
+
+		messageId, err := uuid.Parse(messageIdQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		cursor = &entities.MessageCursor{Direction: direction, MessageID: messageId}
+	}
+
+	return cursor, nil
 }
 
 func getPagination(ctx *gin.Context) (offset int, limit int) {
@@ -812,12 +1903,53 @@ func getResponseServer(server *entities.Server) gin.H {
 	}
 }
 
+func getResponseSearchResult(result *entities.MessageSearchResult) gin.H {
+	return gin.H{
+		"id":                result.ID,
+		"channel_id":        result.ChannelID,
+		"server_id":         result.ServerID,
+		"is_server_message": result.ServerID != nil,
+		"sender_id":         result.SenderID,
+		"parent_id":         result.ParentID,
+		"content":           result.Content,
+		"attachment":        result.Attachment,
+		"sent_at":           result.SentAt,
+		"snippet":           result.Snippet,
+		"rank":              result.Rank,
+	}
+}
+
+func getResponseRole(role *entities.ServerRole) gin.H {
+	return gin.H{
+		"id":          role.ID,
+		"server_id":   role.ServerID,
+		"name":        role.Name,
+		"permissions": role.Permissions.Names(),
+		"position":    role.Position,
+		"created_at":  role.CreatedAt,
+	}
+}
+
+func getResponseInvite(invite *entities.ServerInvite) gin.H {
+	return gin.H{
+		"code":       invite.Code,
+		"server_id":  invite.ServerID,
+		"creator_id": invite.CreatorID,
+		"role_id":    invite.RoleID,
+		"max_uses":   invite.MaxUses,
+		"uses":       invite.Uses,
+		"expires_at": invite.ExpiresAt,
+		"created_at": invite.CreatedAt,
+	}
+}
+
 func getResponseChannel(channel any, isServerChannel bool) gin.H {
 	if isServerChannel {
 		channelModel := channel.(*entities.ServerChannel)
 		return gin.H{
 			"id":          channelModel.ID,
 			"server_id":   channelModel.ServerID,
+			"mode":        channelModel.Mode,
 			"name":        channelModel.Name,
 			"description": channelModel.Description,
 			"created_at":  channelModel.CreatedAt,
@@ -833,6 +1965,16 @@ func getResponseChannel(channel any, isServerChannel bool) gin.H {
 	}
 }
 
+func getResponseOverride(override *entities.ChannelPermissionOverride) gin.H {
+	return gin.H{
+		"channel_id":  override.ChannelID,
+		"target_type": override.TargetType,
+		"target_id":   override.TargetID,
+		"allow":       override.Allow.Names(),
+		"deny":        override.Deny.Names(),
+	}
+}
+
 func getResponseChannelArray(channels any, isServerChannel bool) []gin.H {
 	if isServerChannel {
 		channelsArray := channels.(*[]entities.ServerChannel)
@@ -881,25 +2023,35 @@ func getResponseMessage(message any, isServerMessage bool) gin.H {
 	if isServerMessage {
 		messageModel := message.(*entities.ServerMessage)
 		return gin.H{
-			"id":         messageModel.ID,
-			"content":    messageModel.Content,
-			"attachment": messageModel.Attachment,
-			"channel_id": messageModel.ChannelID,
-			"sender_id":  messageModel.SenderID,
-			"sent_at":    messageModel.SentAt,
-			"updated_at": messageModel.UpdatedAt,
+			"id":            messageModel.ID,
+			"content":       messageModel.Content,
+			"attachment":    messageModel.Attachment,
+			"channel_id":    messageModel.ChannelID,
+			"sender_id":     messageModel.SenderID,
+			"parent_id":     messageModel.ParentID,
+			"nonce":         messageModel.Nonce,
+			"mentions":      entities.GetMessageMentions(message),
+			"reply_count":   messageModel.ReplyCount,
+			"last_reply_at": messageModel.LastReplyAt,
+			"sent_at":       messageModel.SentAt,
+			"updated_at":    messageModel.UpdatedAt,
 		}
 	}
 
 	messageModel := message.(*entities.DirectMessage)
 	return gin.H{
-		"id":         messageModel.ID,
-		"content":    messageModel.Content,
-		"attachment": messageModel.Attachment,
-		"channel_id": messageModel.ChannelID,
-		"sender_id":  messageModel.SenderID,
-		"sent_at":    messageModel.SentAt,
-		"updated_at": messageModel.UpdatedAt,
+		"id":            messageModel.ID,
+		"content":       messageModel.Content,
+		"attachment":    messageModel.Attachment,
+		"channel_id":    messageModel.ChannelID,
+		"sender_id":     messageModel.SenderID,
+		"parent_id":     messageModel.ParentID,
+		"nonce":         messageModel.Nonce,
+		"mentions":      entities.GetMessageMentions(message),
+		"reply_count":   messageModel.ReplyCount,
+		"last_reply_at": messageModel.LastReplyAt,
+		"sent_at":       messageModel.SentAt,
+		"updated_at":    messageModel.UpdatedAt,
 	}
 }
 
@@ -923,7 +2075,54 @@ func getResponseMessageArray(channelMessages any, isServerMessage bool) []gin.H
 	return messagesData
 }
 
+func getResponseRevision(revision *entities.Revision) gin.H {
+	return gin.H{
+		"id":         revision.ID,
+		"message_id": revision.MessageID,
+		"content":    revision.Content,
+		"attachment": revision.Attachment,
+		"edited_at":  revision.EditedAt,
+	}
+}
+
+func getResponseRevisionArray(revisions any, isServerMessage bool) []gin.H {
+	if isServerMessage {
+		revisionsArray := revisions.(*[]entities.ServerMessageRevision)
+		revisionsData := make([]gin.H, len(*revisionsArray))
+		for idx, revision := range *revisionsArray {
+			revisionsData[idx] = getResponseRevision(&revision.Revision)
+		}
+
+		return revisionsData
+	}
+
+	revisionsArray := revisions.(*[]entities.DirectMessageRevision)
+	revisionsData := make([]gin.H, len(*revisionsArray))
+	for idx, revision := range *revisionsArray {
+		revisionsData[idx] = getResponseRevision(&revision.Revision)
+	}
+
+	return revisionsData
+}
+
+func reportAuthorizationError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, application.ErrForbidden):
+		reportError(ctx, http.StatusForbidden, err)
+	case errors.Is(err, ports.ErrNotFound):
+		reportError(ctx, http.StatusNotFound, err)
+	case errors.Is(err, application.ErrValidation):
+		reportError(ctx, http.StatusBadRequest, err)
+	default:
+		reportError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
 func reportError(ctx *gin.Context, errorCode int, err error) {
+	if errorCode == http.StatusInternalServerError && errors.Is(err, ports.ErrNotFound) {
+		errorCode = http.StatusNotFound
+	}
+
 	log.Println(err)
 	ctx.JSON(errorCode, gin.H{
 		"message": err.Error(),
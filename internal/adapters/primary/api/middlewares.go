@@ -16,13 +16,14 @@ func (api *Adapter) authenticate(ctx *gin.Context) {
 
 	token := authHeader[1]
 
-	userId, err := api.app.ValidateJWTToken(token)
+	userId, sessionId, err := api.app.ValidateJWTToken(token)
 	if err != nil {
 		ctx.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
 
 	ctx.Set("user_id", userId)
+	ctx.Set("session_id", sessionId)
 
 	ctx.Next()
 }
@@ -1,13 +1,51 @@
 package api
 
+import "time"
+
 type loginRequest struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
 
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 type createServerRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description" binding:"required"`
 	OwnerID     string `json:"owner_id" binding:"required"`
 	Photo       string `json:"photo"`
 }
+
+type roleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Permissions []string `json:"permissions"`
+	Position    int      `json:"position"`
+}
+
+type overrideRequest struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+type inviteRequest struct {
+	MaxUses   int    `json:"max_uses" binding:"min=0"`
+	ExpiresIn int    `json:"expires_in" binding:"min=0"`
+	RoleID    string `json:"role_id"`
+}
+
+type markReadRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+}
+
+type searchMessagesRequest struct {
+	Query         string     `form:"q" binding:"required"`
+	SenderID      string     `form:"sender_id"`
+	ChannelID     string     `form:"channel_id"`
+	ServerID      string     `form:"server_id"`
+	From          *time.Time `form:"from"`
+	To            *time.Time `form:"to"`
+	HasAttachment *bool      `form:"has_attachment"`
+	MentionsMe    bool       `form:"mentions_me"`
+}
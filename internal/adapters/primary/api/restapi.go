@@ -1,30 +1,36 @@
 package api
 
 import (
+	"sync"
+
 	"github.com/critch-app/critch-backend/internal/application/application"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 type Adapter struct {
-	app    application.AppI
-	router *gin.Engine
+	app             application.AppI
+	router          *gin.Engine
+	websocketConfig WebsocketConfig
+	streams         sync.Map
 }
 
-func NewAdapter(app application.AppI) *Adapter {
+func NewAdapter(app application.AppI, websocketConfig WebsocketConfig) *Adapter {
 	router := gin.Default()
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 	config.AllowHeaders = []string{"Authorization", "Content-Type"}
 	router.Use(cors.New(config))
 
-	api := &Adapter{app: app, router: router}
+	api := &Adapter{app: app, router: router, websocketConfig: newWebsocketConfig(websocketConfig)}
 	api.setupRouting()
 
 	return api
 }
 
 func (api *Adapter) Run() error {
+	go api.receiveStreamFrames()
+
 	return api.router.Run()
 }
 
@@ -33,11 +39,16 @@ func (api *Adapter) setupRouting() {
 
 	v1.POST("/users", api.signup)
 	v1.POST("/login", api.login)
+	v1.POST("/token/refresh", api.refreshToken)
 
 	v1.GET("/messaging-service", api.connectWebsocket)
+	v1.GET("/messaging-service/events", api.streamEvents)
 
 	authorized := v1.Group("/", api.authenticate)
 
+	authorized.POST("/logout", api.logout)
+	authorized.POST("/logout/all", api.logoutAllDevices)
+
 	authorized.GET("/users", api.getAllUsers)
 	authorized.GET("/users/:user-id", api.getUser)
 	authorized.DELETE("/users/:user-id", api.deleteUser)
@@ -54,6 +65,19 @@ func (api *Adapter) setupRouting() {
 	authorized.PUT("/servers/:server-id/users/:user-id", api.addServerMember)
 	authorized.DELETE("/servers/:server-id/users/:user-id", api.removeServerMember)
 	authorized.GET("/servers/:server-id/channels", api.getServerChannels)
+	authorized.GET("/servers/:server-id/invites", api.getServerInvites)
+	authorized.POST("/servers/:server-id/invites", api.createInvite)
+	authorized.DELETE("/servers/:server-id/invites/:code", api.deleteInvite)
+	authorized.GET("/servers/:server-id/roles", api.getServerRoles)
+	authorized.POST("/servers/:server-id/roles", api.createRole)
+	authorized.GET("/servers/:server-id/roles/:role-id", api.getRole)
+	authorized.PATCH("/servers/:server-id/roles/:role-id", api.updateRole)
+	authorized.DELETE("/servers/:server-id/roles/:role-id", api.deleteRole)
+	authorized.PUT("/servers/:server-id/users/:user-id/roles/:role-id", api.addMemberRole)
+	authorized.DELETE("/servers/:server-id/users/:user-id/roles/:role-id", api.removeMemberRole)
+
+	authorized.GET("/invites/:code", api.getInvite)
+	authorized.POST("/invites/:code", api.redeemInvite)
 
 	authorized.GET("/channels", api.getAllChannels)
 	authorized.POST("/channels", api.createChannel)
@@ -64,10 +88,22 @@ func (api *Adapter) setupRouting() {
 	authorized.PUT("/channels/:channel-id/users/:user-id", api.addChannelMember)
 	authorized.DELETE("/channels/:channel-id/users/:user-id", api.removeChannelMember)
 	authorized.GET("/channels/:channel-id/messages", api.getChannelMessages)
+	authorized.PUT("/channels/:channel-id/read", api.markChannelRead)
+	authorized.GET("/channels/:channel-id/overrides", api.getChannelOverrides)
+	authorized.PUT("/channels/:channel-id/overrides/:target-type/:target-id", api.setChannelOverride)
+	authorized.DELETE("/channels/:channel-id/overrides/:target-type/:target-id", api.removeChannelOverride)
 
 	authorized.GET("/messages/:message-id", api.getMessage)
+	authorized.GET("/messages/:message-id/replies", api.getMessageReplies)
+	authorized.GET("/messages/:message-id/history", api.getMessageHistory)
+	authorized.PUT("/messages/:message-id/reactions/:emoji", api.addReaction)
+	authorized.DELETE("/messages/:message-id/reactions/:emoji", api.removeReaction)
 	authorized.DELETE("/messages/:message-id", api.deleteMessage)
 	authorized.PATCH("/messages/:message-id", api.updateMessage)
 
+	authorized.GET("/search/messages", api.searchMessages)
+
 	authorized.GET("/server-role", api.getServerMemberRole)
+	authorized.GET("/messaging-service/stats", api.getMessagingStats)
+	authorized.POST("/messaging-service/events/:connection-id", api.postStreamFrame)
 }
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/critch-app/critch-backend/internal/application/application"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const STREAM_REPLY_QUEUE_SIZE = 64
+
+func (api *Adapter) streamEvents(ctx *gin.Context) {
+	token, exists := ctx.GetQuery("token")
+	if !exists {
+		reportError(ctx, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+
+	lastSeqParam := ctx.Query("last_seq")
+	if lastSeqParam == "" {
+		lastSeqParam = ctx.GetHeader("Last-Event-ID")
+	}
+
+	var lastSeq uint64
+	if lastSeqParam != "" {
+		var err error
+		lastSeq, err = strconv.ParseUint(lastSeqParam, 10, 64)
+		if err != nil {
+			reportError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	version, err := negotiateProtocolVersion(ctx.Query("protocol_version"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	clientId, sessionId, err := api.app.ValidateJWTToken(token)
+	if err != nil {
+		reportError(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	clientObj, err := api.app.ConnectWebsocket(clientId, sessionId, lastSeq)
+	if err != nil {
+		reportError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	client := &connection{
+		clientObj: clientObj,
+		config:    api.websocketConfig,
+		version:   version,
+		limiter:   newRateLimiter(api.websocketConfig.RateLimit, api.websocketConfig.RateBurst),
+		replies:   make(chan any, STREAM_REPLY_QUEUE_SIZE),
+	}
+
+	api.streams.Store(clientObj.ConnectionID, client)
+
+	defer func() {
+		api.streams.Delete(clientObj.ConnectionID)
+		api.app.DisconnectWebsocket(clientObj)
+	}()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	err = client.writeStreamEvent(ctx, newHelloFrame(version, clientObj.ConnectionID))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	heartbeatTicker := time.NewTicker(client.config.PingInterval)
+	defer heartbeatTicker.Stop()
+
+	messages := api.app.ReceiveMessages(clientObj)
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			err = client.writeStreamEvent(ctx, message)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+
+			if isSessionRevoked(message) {
+				return
+			}
+		case reply := <-client.replies:
+			err = client.writeStreamEvent(ctx, reply)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+		case <-heartbeatTicker.C:
+			err = client.writeStreamData(ctx, ": ping\n\n")
+			if err != nil {
+				log.Println(err)
+				return
+			}
+		}
+	}
+}
+
+func (api *Adapter) postStreamFrame(ctx *gin.Context) {
+	connectionId, err := uuid.Parse(ctx.Param("connection-id"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(ctx.Request.Body, api.websocketConfig.MaxMessageSize+1))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if int64(len(payload)) > api.websocketConfig.MaxMessageSize {
+		reportError(ctx, http.StatusRequestEntityTooLarge, errors.New("frame is too large"))
+		return
+	}
+
+	stream, exists := api.streams.Load(connectionId)
+	if !exists {
+		api.app.ForwardStreamFrame(getActorId(ctx), connectionId, payload)
+		ctx.Status(http.StatusAccepted)
+		return
+	}
+
+	client := stream.(*connection)
+	if client.clientObj.ID != getActorId(ctx) {
+		reportError(ctx, http.StatusForbidden, application.ErrForbidden)
+		return
+	}
+
+	api.app.RecordActivity(client.clientObj)
+
+	handleFrame(client, api.app, payload)
+
+	ctx.Status(http.StatusAccepted)
+}
+
+func (api *Adapter) receiveStreamFrames() {
+	for frame := range api.app.ReceiveStreamFrames() {
+		stream, exists := api.streams.Load(frame.ConnectionId)
+		if !exists {
+			continue
+		}
+
+		payload, ok := frame.Message.(string)
+		client := stream.(*connection)
+		if !ok || client.clientObj.ID != frame.SenderId {
+			continue
+		}
+
+		api.app.RecordActivity(client.clientObj)
+
+		handleFrame(client, api.app, []byte(payload))
+	}
+}
+
+func (client *connection) writeStreamEvent(ctx *gin.Context, message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	event := fmt.Sprintf("data: %s\n\n", data)
+	if messageMap, ok := message.(map[string]any); ok {
+		if seq, exists := messageMap["seq"]; exists {
+			event = fmt.Sprintf("id: %v\n%s", seq, event)
+		}
+	}
+
+	return client.writeStreamData(ctx, event)
+}
+
+func (client *connection) writeStreamData(ctx *gin.Context, data string) error {
+	responseController := http.NewResponseController(ctx.Writer)
+	responseController.SetWriteDeadline(time.Now().Add(client.config.WriteTimeout))
+
+	_, err := io.WriteString(ctx.Writer, data)
+	if err != nil {
+		return err
+	}
+
+	ctx.Writer.Flush()
+	return nil
+}
@@ -3,17 +3,131 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/critch-app/critch-backend/internal/application/application"
 	"github.com/critch-app/critch-backend/internal/application/core/msgsrvc"
+	"github.com/critch-app/critch-backend/internal/ports"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"log"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 )
 
+const (
+	DEFAULT_PING_INTERVAL    = 30 * time.Second
+	DEFAULT_PONG_TIMEOUT     = 60 * time.Second
+	DEFAULT_WRITE_TIMEOUT    = 10 * time.Second
+	DEFAULT_MAX_MESSAGE_SIZE = 64 * 1024
+	DEFAULT_RATE_LIMIT       = 20
+	DEFAULT_RATE_BURST       = 40
+)
+
+const (
+	PROTOCOL_VERSION     = 2
+	MIN_PROTOCOL_VERSION = 1
+)
+
+const (
+	FORBIDDEN_ERROR           = "forbidden"
+	NOT_FOUND_ERROR           = "not_found"
+	VALIDATION_ERROR          = "validation"
+	RATE_LIMITED_ERROR        = "rate_limited"
+	UNAUTHORIZED_ERROR        = "unauthorized"
+	UNKNOWN_TYPE_ERROR        = "unknown_type"
+	UNSUPPORTED_VERSION_ERROR = "unsupported_version"
+	INTERNAL_ERROR            = "internal"
+)
+
+var (
+	errRateLimited        = errors.New("rate limit exceeded")
+	errUnknownType        = errors.New("unknown message type")
+	errUnsupportedVersion = errors.New("unsupported protocol version")
+	errStreamBackedUp     = errors.New("event stream is backed up")
+)
+
+type WebsocketConfig struct {
+	PingInterval   time.Duration
+	PongTimeout    time.Duration
+	WriteTimeout   time.Duration
+	MaxMessageSize int64
+	RateLimit      float64
+	RateBurst      int
+}
+
 type connection struct {
 	clientObj           *msgsrvc.Client
 	websocketConnection *websocket.Conn
+	config              WebsocketConfig
+	version             int
+	limiter             *rateLimiter
+	replies             chan any
+	writeMutex          sync.Mutex
+}
+
+type rateLimiter struct {
+	rate       float64
+	burst      float64
+	tokens     float64
+	lastRefill time.Time
+	mutex      sync.Mutex
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:       rate,
+		burst:      float64(burst),
+		tokens:     float64(burst),
+		lastRefill: time.Now(),
+	}
+}
+
+func (limiter *rateLimiter) allow() bool {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := time.Now()
+	limiter.tokens = min(limiter.burst, limiter.tokens+now.Sub(limiter.lastRefill).Seconds()*limiter.rate)
+	limiter.lastRefill = now
+
+	if limiter.tokens < 1 {
+		return false
+	}
+
+	limiter.tokens--
+	return true
+}
+
+func newWebsocketConfig(config WebsocketConfig) WebsocketConfig {
+	if config.PongTimeout <= 0 {
+		config.PongTimeout = DEFAULT_PONG_TIMEOUT
+	}
+
+	if config.PingInterval <= 0 || config.PingInterval >= config.PongTimeout {
+		config.PingInterval = min(DEFAULT_PING_INTERVAL, config.PongTimeout*9/10)
+	}
+
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = DEFAULT_WRITE_TIMEOUT
+	}
+
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = DEFAULT_MAX_MESSAGE_SIZE
+	}
+
+	if config.RateLimit <= 0 {
+		config.RateLimit = DEFAULT_RATE_LIMIT
+	}
+
+	if config.RateBurst <= 0 {
+		config.RateBurst = DEFAULT_RATE_BURST
+	}
+
+	return config
 }
 
 func (api *Adapter) connectWebsocket(ctx *gin.Context) {
@@ -31,22 +145,38 @@ func (api *Adapter) connectWebsocket(ctx *gin.Context) {
 		return
 	}
 
+	var lastSeq uint64
+	if lastSeqParam, exists := ctx.GetQuery("last_seq"); exists {
+		var err error
+		lastSeq, err = strconv.ParseUint(lastSeqParam, 10, 64)
+		if err != nil {
+			reportError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	version, err := negotiateProtocolVersion(ctx.Query("protocol_version"))
+	if err != nil {
+		reportError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
 	websocketConnection, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
 		reportError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	clientId, err := api.app.ValidateJWTToken(token)
+	clientId, sessionId, err := api.app.ValidateJWTToken(token)
 	if err != nil {
-		reportWebsocketError(websocketConnection, err)
+		reportWebsocketError(websocketConnection, UNAUTHORIZED_ERROR, err)
 		websocketConnection.Close()
 		return
 	}
 
-	clientObj, err := api.app.ConnectWebsocket(clientId)
+	clientObj, err := api.app.ConnectWebsocket(clientId, sessionId, lastSeq)
 	if err != nil {
-		reportWebsocketError(websocketConnection, err)
+		reportWebsocketError(websocketConnection, websocketErrorCode(err), err)
 		websocketConnection.Close()
 		return
 	}
@@ -54,6 +184,13 @@ func (api *Adapter) connectWebsocket(ctx *gin.Context) {
 	client := &connection{
 		clientObj:           clientObj,
 		websocketConnection: websocketConnection,
+		config:              api.websocketConfig,
+		version:             version,
+		limiter:             newRateLimiter(api.websocketConfig.RateLimit, api.websocketConfig.RateBurst),
+	}
+
+	if version >= 2 {
+		client.writeJSON(newHelloFrame(version, clientObj.ConnectionID))
 	}
 
 	go receiveMessages(client, api.app)
@@ -61,21 +198,168 @@ func (api *Adapter) connectWebsocket(ctx *gin.Context) {
 }
 
 func receiveMessages(client *connection, app application.AppI) {
+	pingTicker := time.NewTicker(client.config.PingInterval)
+
 	defer func() {
+		pingTicker.Stop()
 		client.websocketConnection.Close()
 	}()
 
+	messages := app.ReceiveMessages(client.clientObj)
+
 	for {
-		message, ok := app.ReceiveMessages(client.clientObj)
-		if !ok {
-			return
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				client.websocketConnection.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+					time.Now().Add(client.config.WriteTimeout))
+				return
+			}
+
+			err := client.writeJSON(message)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+
+			if isSessionRevoked(message) {
+				return
+			}
+		case <-pingTicker.C:
+			err := client.websocketConnection.WriteControl(websocket.PingMessage, []byte{},
+				time.Now().Add(client.config.WriteTimeout))
+			if err != nil {
+				log.Println(err)
+				return
+			}
 		}
+	}
+}
 
-		err := client.websocketConnection.WriteJSON(message)
-		if err != nil {
-			reportWebsocketError(client.websocketConnection, err)
+func (client *connection) writeJSON(message any) error {
+	if client.replies != nil {
+		select {
+		case client.replies <- message:
+			return nil
+		default:
+			return errStreamBackedUp
 		}
 	}
+
+	client.writeMutex.Lock()
+	defer client.writeMutex.Unlock()
+
+	client.websocketConnection.SetWriteDeadline(time.Now().Add(client.config.WriteTimeout))
+	return client.websocketConnection.WriteJSON(message)
+}
+
+func (client *connection) extendReadDeadline() error {
+	return client.websocketConnection.SetReadDeadline(time.Now().Add(client.config.PongTimeout))
+}
+
+func (client *connection) writeFrame(request *webSocketMessage, frameType string, data any) error {
+	frame := map[string]any{
+		"type": frameType,
+		"data": data,
+	}
+
+	if client.version >= 2 {
+		frame["v"] = client.version
+		frame["request_id"] = request.RequestID
+	}
+
+	return client.writeJSON(frame)
+}
+
+func (client *connection) respond(request *webSocketMessage) {
+	if client.version < 2 {
+		return
+	}
+
+	client.writeFrame(request, msgsrvc.RESPONSE, map[string]any{
+		"type": request.MessageType,
+	})
+}
+
+func (client *connection) reportError(request *webSocketMessage, err error) {
+	log.Println(err)
+	client.writeFrame(request, msgsrvc.ERROR, getWebsocketError(err))
+}
+
+func negotiateProtocolVersion(requestedVersion string) (int, error) {
+	if requestedVersion == "" {
+		return MIN_PROTOCOL_VERSION, nil
+	}
+
+	version, err := strconv.Atoi(requestedVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	if version < MIN_PROTOCOL_VERSION {
+		return 0, fmt.Errorf("%w: supported versions are %d to %d", errUnsupportedVersion,
+			MIN_PROTOCOL_VERSION, PROTOCOL_VERSION)
+	}
+
+	return min(version, PROTOCOL_VERSION), nil
+}
+
+func newHelloFrame(version int, connectionId uuid.UUID) map[string]any {
+	return map[string]any{
+		"v":    version,
+		"type": msgsrvc.HELLO,
+		"data": map[string]any{
+			"version":       version,
+			"min_version":   MIN_PROTOCOL_VERSION,
+			"max_version":   PROTOCOL_VERSION,
+			"connection_id": connectionId,
+		},
+	}
+}
+
+func decodeWebsocketData(data []byte, message any) error {
+	err := json.Unmarshal(data, message)
+	if err != nil {
+		return fmt.Errorf("%w: %w", application.ErrValidation, err)
+	}
+
+	err = binding.Validator.ValidateStruct(message)
+	if err != nil {
+		return fmt.Errorf("%w: %w", application.ErrValidation, err)
+	}
+
+	return nil
+}
+
+func websocketErrorCode(err error) string {
+	switch {
+	case errors.Is(err, application.ErrForbidden):
+		return FORBIDDEN_ERROR
+	case errors.Is(err, ports.ErrNotFound):
+		return NOT_FOUND_ERROR
+	case errors.Is(err, application.ErrValidation):
+		return VALIDATION_ERROR
+	case errors.Is(err, errRateLimited):
+		return RATE_LIMITED_ERROR
+	case errors.Is(err, errUnknownType):
+		return UNKNOWN_TYPE_ERROR
+	case errors.Is(err, errUnsupportedVersion):
+		return UNSUPPORTED_VERSION_ERROR
+	default:
+		return INTERNAL_ERROR
+	}
+}
+
+func getWebsocketError(err error) map[string]any {
+	return map[string]any{
+		"code":    websocketErrorCode(err),
+		"message": err.Error(),
+	}
+}
+
+func isSessionRevoked(message any) bool {
+	messageMap, ok := message.(map[string]any)
+	return ok && messageMap["type"] == msgsrvc.SESSION_REVOKED
 }
 
 func sendMessages(client *connection, app application.AppI) {
@@ -84,143 +368,216 @@ func sendMessages(client *connection, app application.AppI) {
 		client.websocketConnection.Close()
 	}()
 
+	client.websocketConnection.SetReadLimit(client.config.MaxMessageSize)
+	client.extendReadDeadline()
+	client.websocketConnection.SetPongHandler(func(string) error {
+		return client.extendReadDeadline()
+	})
+
 	for {
-		wsMessage := &webSocketMessage{}
-		err := client.websocketConnection.ReadJSON(wsMessage)
+		_, payload, err := client.websocketConnection.ReadMessage()
 		if err != nil {
-			reportWebsocketError(client.websocketConnection, err)
+			log.Println(err)
 			return
 		}
 
-		switch wsMessage.MessageType {
-		case msgsrvc.MESSAGE:
-			message := &msgsrvc.IncomingMessage{}
+		client.extendReadDeadline()
 
-			err = json.Unmarshal(wsMessage.Data, &message)
+		app.RecordActivity(client.clientObj)
 
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
+		handleFrame(client, app, payload)
+	}
+}
 
-			message.SenderId = client.clientObj.ID
-			err = app.SendMessages(message)
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
-		case msgsrvc.JOIN_CHANNEL:
-			message := &msgsrvc.JoinChannel{}
+func handleFrame(client *connection, app application.AppI, payload []byte) {
+	wsMessage := &webSocketMessage{}
+	err := decodeWebsocketData(payload, wsMessage)
+	if err != nil {
+		client.reportError(wsMessage, err)
+		return
+	}
 
-			err = json.Unmarshal(wsMessage.Data, &message)
+	if !client.limiter.allow() {
+		client.reportError(wsMessage, errRateLimited)
+		return
+	}
 
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
+	if wsMessage.Version != 0 && wsMessage.Version != client.version {
+		client.reportError(wsMessage, fmt.Errorf("%w: connection negotiated version %d", errUnsupportedVersion,
+			client.version))
+		return
+	}
 
-			message.SenderId = client.clientObj.ID
+	switch wsMessage.MessageType {
+	case msgsrvc.MESSAGE:
+		message := &msgsrvc.IncomingMessage{}
 
-			app.JoinChannels(client.clientObj, message.ServerId, message.Channels)
+		err = decodeWebsocketData(wsMessage.Data, message)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			err = app.SendNotification(wsMessage, message.ServerId)
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
-		case msgsrvc.QUIT_CHANNEL:
-			message := &msgsrvc.QuitChannel{}
+		message.SenderId = client.clientObj.ID
+		persistedMessage, err := app.SendMessages(message)
+		if err != nil {
+			log.Println(err)
+			errorData := getWebsocketError(err)
+			errorData["nonce"] = message.Nonce
+			client.writeFrame(wsMessage, msgsrvc.ERROR, errorData)
+			return
+		}
 
-			err = json.Unmarshal(wsMessage.Data, &message)
+		client.writeFrame(wsMessage, msgsrvc.ACK, map[string]any{
+			"nonce":   message.Nonce,
+			"message": getResponseMessage(persistedMessage, message.ServerId != uuid.Nil),
+		})
+	case msgsrvc.TYPING_START, msgsrvc.TYPING_STOP:
+		message := &msgsrvc.Typing{}
 
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
+		err = decodeWebsocketData(wsMessage.Data, message)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			message.SenderId = client.clientObj.ID
+		message.Type = wsMessage.MessageType
+		message.SenderId = client.clientObj.ID
 
-			app.QuitChannel(client.clientObj, message.ChannelId)
+		err = app.SendTyping(message)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			err = app.SendNotification(wsMessage, message.ServerId)
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
-		case msgsrvc.QUIT_SERVER:
-			message := &msgsrvc.QuitServer{}
+		client.respond(wsMessage)
+	case msgsrvc.MARK_READ:
+		message := &msgsrvc.MarkRead{}
 
-			err = json.Unmarshal(wsMessage.Data, &message)
+		err = decodeWebsocketData(wsMessage.Data, message)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
+		message.SenderId = client.clientObj.ID
+		isServerChannel := message.ServerId != uuid.Nil
 
-			message.SenderId = client.clientObj.ID
+		err = app.AuthorizeMessagingChannel(message.SenderId, message.ServerId, message.ChannelId, application.VIEW_CHANNEL)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			app.QuitServer(client.clientObj, message.ServerId)
+		err = app.MarkRead(message, isServerChannel)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			err = app.SendNotification(wsMessage, message.ServerId)
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
-		case msgsrvc.REMOVE_CHANNEL:
-			message := &msgsrvc.RemoveChannel{}
+		client.respond(wsMessage)
+	case msgsrvc.JOIN_CHANNEL:
+		message := &msgsrvc.JoinChannel{}
 
-			err = json.Unmarshal(wsMessage.Data, &message)
+		err = decodeWebsocketData(wsMessage.Data, message)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
+		message.SenderId = client.clientObj.ID
 
-			message.SenderId = client.clientObj.ID
+		err = app.JoinChannels(client.clientObj, message.ServerId, message.Channels)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			app.RemoveChannel(message.ChannelId)
+		err = app.SendNotification(wsMessage.notification(), message.ServerId)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			err = app.SendNotification(wsMessage, message.ServerId)
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
-		case msgsrvc.REMOVE_SERVER:
-			message := &msgsrvc.RemoveServer{}
+		client.respond(wsMessage)
+	case msgsrvc.QUIT_CHANNEL:
+		message := &msgsrvc.QuitChannel{}
+
+		err = decodeWebsocketData(wsMessage.Data, message)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			err = json.Unmarshal(wsMessage.Data, &message)
+		message.SenderId = client.clientObj.ID
 
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
+		err = app.AuthorizeServer(message.SenderId, message.ServerId, application.VIEW_SERVER)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			message.SenderId = client.clientObj.ID
+		app.QuitChannel(client.clientObj, message.ChannelId)
 
-			app.RemoveServer(message.ServerId)
+		err = app.SendNotification(wsMessage.notification(), message.ServerId)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
 
-			err = app.SendNotification(wsMessage, message.ServerId)
-			if err != nil {
-				reportWebsocketError(client.websocketConnection, err)
-				continue
-			}
-		default:
-			log.Println("Unhandled websocket message: ", wsMessage.MessageType)
+		client.respond(wsMessage)
+	case msgsrvc.QUIT_SERVER:
+		message := &msgsrvc.QuitServer{}
+
+		err = decodeWebsocketData(wsMessage.Data, message)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
 		}
+
+		message.SenderId = client.clientObj.ID
+
+		err = app.AuthorizeServer(message.SenderId, message.ServerId, application.VIEW_SERVER)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
+
+		app.QuitServer(client.clientObj, message.ServerId)
+
+		err = app.SendNotification(wsMessage.notification(), message.ServerId)
+		if err != nil {
+			client.reportError(wsMessage, err)
+			return
+		}
+
+		client.respond(wsMessage)
+	default:
+		client.reportError(wsMessage, fmt.Errorf("%w: %s", errUnknownType, wsMessage.MessageType))
 	}
 }
 
-func reportWebsocketError(websocketConnection *websocket.Conn, err error) {
+func reportWebsocketError(websocketConnection *websocket.Conn, code string, err error) {
 	log.Println(err)
 	websocketConnection.WriteJSON(map[string]any{
 		"type": "error",
 		"data": map[string]any{
+			"code":    code,
 			"message": err.Error(),
 		},
 	})
 }
 
 type webSocketMessage struct {
+	Version     int             `json:"v,omitempty"`
 	MessageType string          `json:"type"  binding:"required"`
+	RequestID   string          `json:"request_id,omitempty"`
 	Data        json.RawMessage `json:"data"  binding:"required"`
 }
+
+func (wsMessage *webSocketMessage) notification() *webSocketMessage {
+	return &webSocketMessage{
+		MessageType: wsMessage.MessageType,
+		Data:        wsMessage.Data,
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		requested   string
+		wantVersion int
+		wantErr     error
+	}{
+		{"missing version falls back to the oldest", "", MIN_PROTOCOL_VERSION, nil},
+		{"oldest version", "1", 1, nil},
+		{"current version", "2", PROTOCOL_VERSION, nil},
+		{"newer version is capped", "99", PROTOCOL_VERSION, nil},
+		{"version below the minimum", "0", 0, errUnsupportedVersion},
+		{"negative version", "-1", 0, errUnsupportedVersion},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			version, err := negotiateProtocolVersion(test.requested)
+
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("err = %v, want %v", err, test.wantErr)
+			}
+
+			if version != test.wantVersion {
+				t.Errorf("version = %d, want %d", version, test.wantVersion)
+			}
+		})
+	}
+
+	t.Run("non numeric version", func(t *testing.T) {
+		_, err := negotiateProtocolVersion("v2")
+		if err == nil {
+			t.Error("expected an error for a non numeric version")
+		}
+	})
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	limiter := newRateLimiter(1, 3)
+
+	for idx := 0; idx < 3; idx++ {
+		if !limiter.allow() {
+			t.Fatalf("frame %d was rejected within the burst", idx+1)
+		}
+	}
+
+	if limiter.allow() {
+		t.Error("frame past the burst was allowed")
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	limiter := newRateLimiter(2, 4)
+	limiter.tokens = 0
+	limiter.lastRefill = time.Now().Add(-time.Second)
+
+	allowed := 0
+	for limiter.allow() {
+		allowed++
+	}
+
+	if allowed != 2 {
+		t.Errorf("allowed %d frames after a second at 2 per second, want 2", allowed)
+	}
+
+	limiter.lastRefill = time.Now().Add(-time.Hour)
+	allowed = 0
+	for limiter.allow() {
+		allowed++
+	}
+
+	if allowed != 4 {
+		t.Errorf("allowed %d frames after a long pause, want the burst of 4", allowed)
+	}
+}
+
+func TestRateLimiterConcurrentFrames(t *testing.T) {
+	limiter := newRateLimiter(0, 25)
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for idx := 0; idx < 100; idx++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if limiter.allow() {
+				allowed.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if allowed.Load() != 25 {
+		t.Errorf("allowed %d concurrent frames, want the burst of 25", allowed.Load())
+	}
+}
@@ -0,0 +1,41 @@
+package broker
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type MemoryBroker struct {
+	messages chan []byte
+	seq      atomic.Uint64
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	mb := &MemoryBroker{
+		messages: make(chan []byte, 100),
+	}
+
+	mb.seq.Store(uint64(time.Now().UnixNano()))
+
+	return mb
+}
+
+func (mb *MemoryBroker) NextSeq() (uint64, error) {
+	return mb.seq.Add(1), nil
+}
+
+func (mb *MemoryBroker) Publish(payload []byte) error {
+	mb.messages <- payload
+
+	return nil
+}
+
+func (mb *MemoryBroker) Messages() <-chan []byte {
+	return mb.messages
+}
+
+func (mb *MemoryBroker) Close() error {
+	close(mb.messages)
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package broker
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	NOTIFY_CHANNEL     = "critch_events"
+	EVENT_SEQUENCE     = "broker_event_seq"
+	MAX_NOTIFY_PAYLOAD = 7900
+	REFERENCE_PREFIX   = "ref:"
+	RECONNECT_DELAY    = time.Second
+)
+
+type PostgresBroker struct {
+	dsn      string
+	pool     *pgxpool.Pool
+	messages chan []byte
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+func NewPostgresBroker(dsn string) (*PostgresBroker, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	_, err = pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS broker_events (
+		id BIGSERIAL PRIMARY KEY,
+		payload BYTEA NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+
+	if err == nil {
+		_, err = pool.Exec(ctx, "CREATE SEQUENCE IF NOT EXISTS "+EVENT_SEQUENCE)
+	}
+
+	if err != nil {
+		pool.Close()
+		cancel()
+		return nil, err
+	}
+
+	pb := &PostgresBroker{
+		dsn:      dsn,
+		pool:     pool,
+		messages: make(chan []byte, 100),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	go pb.listen()
+
+	return pb, nil
+}
+
+func (pb *PostgresBroker) NextSeq() (uint64, error) {
+	var seq int64
+	err := pb.pool.QueryRow(pb.ctx, "SELECT nextval($1)", EVENT_SEQUENCE).Scan(&seq)
+
+	return uint64(seq), err
+}
+
+func (pb *PostgresBroker) Publish(payload []byte) error {
+	notification := string(payload)
+
+	if len(payload) > MAX_NOTIFY_PAYLOAD {
+		var id int64
+		err := pb.pool.QueryRow(pb.ctx, "INSERT INTO broker_events (payload) VALUES ($1) RETURNING id", payload).
+			Scan(&id)
+
+		if err != nil {
+			return err
+		}
+
+		notification = REFERENCE_PREFIX + strconv.FormatInt(id, 10)
+
+		_, err = pb.pool.Exec(pb.ctx, "DELETE FROM broker_events WHERE created_at < now() - interval '5 minutes'")
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	_, err := pb.pool.Exec(pb.ctx, "SELECT pg_notify($1, $2)", NOTIFY_CHANNEL, notification)
+
+	return err
+}
+
+func (pb *PostgresBroker) Messages() <-chan []byte {
+	return pb.messages
+}
+
+func (pb *PostgresBroker) Close() error {
+	pb.cancel()
+	pb.pool.Close()
+
+	return nil
+}
+
+func (pb *PostgresBroker) listen() {
+	defer close(pb.messages)
+
+	for pb.ctx.Err() == nil {
+		err := pb.receive()
+		if err != nil && pb.ctx.Err() == nil {
+			log.Println("broker listener failed: ", err)
+			time.Sleep(RECONNECT_DELAY)
+		}
+	}
+}
+
+func (pb *PostgresBroker) receive() error {
+	conn, err := pgx.Connect(pb.ctx, pb.dsn)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(pb.ctx, "LISTEN "+NOTIFY_CHANNEL)
+	if err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(pb.ctx)
+		if err != nil {
+			return err
+		}
+
+		payload := []byte(notification.Payload)
+
+		if strings.HasPrefix(notification.Payload, REFERENCE_PREFIX) {
+			id, err := strconv.ParseInt(strings.TrimPrefix(notification.Payload, REFERENCE_PREFIX), 10, 64)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			err = conn.QueryRow(pb.ctx, "SELECT payload FROM broker_events WHERE id = $1", id).Scan(&payload)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+		}
+
+		pb.messages <- payload
+	}
+}
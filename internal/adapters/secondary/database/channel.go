@@ -4,6 +4,9 @@ import (
 	"errors"
 	"github.com/critch-app/critch-backend/internal/application/core/entities"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
 )
 
 func (dbA *Adapter) CreateChannel(channel any) error {
@@ -24,13 +27,20 @@ func (dbA *Adapter) GetChannel(channel any) error {
 	return dbA.db.First(channel).Error
 }
 
-func (dbA *Adapter) GetAllChannels(channels any, offset, limit int) error {
+func (dbA *Adapter) GetAllChannels(channels any, userId uuid.UUID, offset, limit int) error {
 	err := validateChannelArrayType(channels)
 	if err != nil {
 		return err
 	}
 
-	return dbA.db.Offset(offset).Limit(limit).Find(channels).Error
+	var channelMember any = &entities.DMChannelMember{}
+	if _, ok := channels.(*[]entities.ServerChannel); ok {
+		channelMember = &entities.ServerChannelMember{}
+	}
+
+	return dbA.db.Offset(offset).Limit(limit).
+		Where("id IN (?)", dbA.db.Model(channelMember).Select("channel_id").Where("user_id = ?", userId)).
+		Find(channels).Error
 }
 
 func (dbA *Adapter) UpdateChannel(channel any) error {
@@ -39,6 +49,10 @@ func (dbA *Adapter) UpdateChannel(channel any) error {
 		return err
 	}
 
+	if serverChannel, ok := channel.(*entities.ServerChannel); ok && serverChannel.Mode != "" {
+		return dbA.db.Model(channel).Select("name", "description", "mode").Updates(channel).Error
+	}
+
 	return dbA.db.Model(channel).Select("name", "description").Updates(channel).Error
 }
 
@@ -52,6 +66,15 @@ func (dbA *Adapter) GetChannelMembers(channelMembers any, channelId uuid.UUID, o
 		Find(channelMembers, "channel_id = ?", channelId).Error
 }
 
+func (dbA *Adapter) GetChannelMember(channelMember any) error {
+	err := validateChannelMembersType(channelMember)
+	if err != nil {
+		return err
+	}
+
+	return dbA.db.Where(channelMember).First(channelMember).Error
+}
+
 func (dbA *Adapter) AddChannelMember(channelMember any) error {
 	err := validateChannelMembersType(channelMember)
 	if err != nil {
@@ -70,14 +93,79 @@ func (dbA *Adapter) RemoveChannelMember(channelMember any) error {
 	return dbA.db.Delete(channelMember).Error
 }
 
-func (dbA *Adapter) GetChannelMessages(channelMessages any, channelId uuid.UUID, offset, limit int) error {
+func (dbA *Adapter) GetChannelMessages(channelMessages any, channelId uuid.UUID, cursor *entities.MessageCursor, limit int) error {
 	err := validateChannelMessageType(channelMessages)
 	if err != nil {
 		return err
 	}
 
-	return dbA.db.Offset(offset).Limit(limit).Order("sent_at").
-		Find(channelMessages, "channel_id = ?", channelId).Error
+	if cursor == nil {
+		return dbA.channelHistory(channelId).Preload("Mentions").Order("sent_at DESC, id DESC").Limit(limit).
+			Find(channelMessages).Error
+	}
+
+	anchor := newChannelMessage(channelMessages)
+	err = dbA.db.First(anchor, "id = ? AND channel_id = ?", cursor.MessageID, channelId).Error
+	if err != nil {
+		return err
+	}
+
+	anchorModel := entities.GetMessageModel(anchor)
+
+	switch cursor.Direction {
+	case entities.BEFORE_CURSOR:
+		return dbA.channelHistory(channelId).Preload("Mentions").
+			Where("(sent_at, id) < (?, ?)", anchorModel.SentAt, anchorModel.ID).
+			Order("sent_at DESC, id DESC").Limit(limit).Find(channelMessages).Error
+	case entities.AFTER_CURSOR:
+		err = dbA.channelHistory(channelId).Preload("Mentions").
+			Where("(sent_at, id) > (?, ?)", anchorModel.SentAt, anchorModel.ID).
+			Order("sent_at, id").Limit(limit).Find(channelMessages).Error
+		if err != nil {
+			return err
+		}
+
+		reverseChannelMessages(channelMessages)
+		return nil
+	case entities.AROUND_CURSOR:
+		newerMessages := []entities.Message{}
+		err = dbA.channelHistory(channelId).Model(newChannelMessage(channelMessages)).Select("id", "sent_at").
+			Where("(sent_at, id) > (?, ?)", anchorModel.SentAt, anchorModel.ID).
+			Order("sent_at, id").Limit((limit - 1) / 2).Find(&newerMessages).Error
+		if err != nil {
+			return err
+		}
+
+		boundary := anchorModel
+		if len(newerMessages) > 0 {
+			boundary = &newerMessages[len(newerMessages)-1]
+		}
+
+		return dbA.channelHistory(channelId).Preload("Mentions").
+			Where("(sent_at, id) <= (?, ?)", boundary.SentAt, boundary.ID).
+			Order("sent_at DESC, id DESC").Limit(limit).Find(channelMessages).Error
+	}
+
+	return errors.New("invalid message cursor")
+}
+
+func (dbA *Adapter) channelHistory(channelId uuid.UUID) *gorm.DB {
+	return dbA.db.Where("channel_id = ? AND parent_id IS NULL", channelId)
+}
+
+func (dbA *Adapter) GetChannelOverrides(channelId uuid.UUID) (*[]entities.ChannelPermissionOverride, error) {
+	overrides := &[]entities.ChannelPermissionOverride{}
+	err := dbA.db.Find(overrides, "channel_id = ?", channelId).Error
+
+	return overrides, err
+}
+
+func (dbA *Adapter) SetChannelOverride(override *entities.ChannelPermissionOverride) error {
+	return dbA.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(override).Error
+}
+
+func (dbA *Adapter) RemoveChannelOverride(override *entities.ChannelPermissionOverride) error {
+	return dbA.db.Delete(override).Error
 }
 
 func (dbA *Adapter) DeleteChannel(channel any) error {
@@ -144,6 +232,26 @@ func validateChannelMessageType(messages any) error {
 	return nil
 }
 
+func newChannelMessage(messages any) any {
+	switch messages.(type) {
+	case *[]entities.ServerMessage:
+		return &entities.ServerMessage{}
+	case *[]entities.DirectMessage:
+		return &entities.DirectMessage{}
+	}
+
+	return nil
+}
+
+func reverseChannelMessages(messages any) {
+	switch messages := messages.(type) {
+	case *[]entities.ServerMessage:
+		slices.Reverse(*messages)
+	case *[]entities.DirectMessage:
+		slices.Reverse(*messages)
+	}
+}
+
 func addChannelID(channel any) error {
 	err := validateChannelType(channel)
 	if err != nil {
@@ -1,6 +1,13 @@
 package database
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/ports"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -16,9 +23,68 @@ func NewAdapter(dsn string) (*Adapter, error) {
 		return nil, err
 	}
 
+	err = db.Callback().Query().After("gorm:query").Register("critch:not_found", translateNotFound)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Adapter{db}, nil
 }
 
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && strings.HasSuffix(pgErr.ConstraintName, constraint)
+}
+
+func translateNotFound(db *gorm.DB) {
+	if errors.Is(db.Error, gorm.ErrRecordNotFound) && !errors.Is(db.Error, ports.ErrNotFound) {
+		db.Error = fmt.Errorf("%w: %w", ports.ErrNotFound, db.Error)
+	}
+}
+
 func (dbA *Adapter) Migrate(models ...any) error {
-	return dbA.db.AutoMigrate(models...)
+	err := dbA.migrateUserPresence()
+	if err != nil {
+		return err
+	}
+
+	err = dbA.migrateMessageNonces()
+	if err != nil {
+		return err
+	}
+
+	err = dbA.db.AutoMigrate(models...)
+	if err != nil {
+		return err
+	}
+
+	return dbA.migrateMessageSearch()
+}
+
+func (dbA *Adapter) migrateUserPresence() error {
+	migrator := dbA.db.Migrator()
+	if !migrator.HasTable(&entities.User{}) {
+		return nil
+	}
+
+	if migrator.HasConstraint(&entities.User{}, "chk_users_status") {
+		err := migrator.DropConstraint(&entities.User{}, "chk_users_status")
+		if err != nil {
+			return err
+		}
+	}
+
+	columnTypes, err := migrator.ColumnTypes(&entities.User{})
+	if err != nil {
+		return err
+	}
+
+	for _, columnType := range columnTypes {
+		if columnType.Name() == "last_seen" && columnType.DatabaseTypeName() == "text" {
+			return dbA.db.Exec("ALTER TABLE users ALTER COLUMN last_seen TYPE timestamptz USING NULL").Error
+		}
+	}
+
+	return nil
 }
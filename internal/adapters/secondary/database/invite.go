@@ -0,0 +1,65 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/ports"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (dbA *Adapter) CreateInvite(invite *entities.ServerInvite) error {
+	return dbA.db.Create(invite).Error
+}
+
+func (dbA *Adapter) GetInvite(code string) (*entities.ServerInvite, error) {
+	invite := &entities.ServerInvite{}
+	err := dbA.db.First(invite, "code = ?", code).Error
+
+	return invite, err
+}
+
+func (dbA *Adapter) GetServerInvites(serverId uuid.UUID, offset, limit int) (*[]entities.ServerInvite, error) {
+	invites := &[]entities.ServerInvite{}
+	err := dbA.db.Offset(offset).Limit(limit).Order("created_at DESC").
+		Find(invites, "server_id = ?", serverId).Error
+
+	return invites, err
+}
+
+func (dbA *Adapter) RedeemInvite(code string, member *entities.ServerMember) (*entities.ServerInvite, *[]uuid.UUID, error) {
+	invite := &entities.ServerInvite{}
+	channelIds := &[]uuid.UUID{}
+
+	err := dbA.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.ServerInvite{}).
+			Where("code = ? AND (max_uses = 0 OR uses < max_uses) AND (expires_at IS NULL OR expires_at > ?)", code, time.Now()).
+			Update("uses", gorm.Expr("uses + 1"))
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: invite is invalid or expired", ports.ErrNotFound)
+		}
+
+		err := tx.First(invite, "code = ?", code).Error
+		if err != nil {
+			return err
+		}
+
+		member.ServerID = invite.ServerID
+		channelIds, err = joinServer(tx, member, invite.RoleID)
+
+		return err
+	})
+
+	return invite, channelIds, err
+}
+
+func (dbA *Adapter) DeleteInvite(code string) error {
+	return dbA.db.Delete(&entities.ServerInvite{}, "code = ?", code).Error
+}
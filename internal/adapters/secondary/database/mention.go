@@ -0,0 +1,72 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/google/uuid"
+)
+
+const mentionsUserCondition = `(mn.target_type IN ('user', 'here') AND mn.target_id = %[1]s) OR mn.target_type = 'everyone' OR
+	(mn.target_type = 'role' AND mn.target_id IN (SELECT role_id FROM server_member_roles WHERE user_id = %[1]s))`
+
+const mentionedMembersQuery = `SELECT DISTINCT cm.user_id FROM %s AS mn
+	JOIN %s AS cm ON cm.channel_id = @channel
+	WHERE mn.message_id = @message AND cm.user_id <> @sender AND (%s)
+	AND EXISTS (SELECT 1 FROM %s AS m WHERE m.id = @message AND m.deleted_at IS NULL)`
+
+func (dbA *Adapter) GetMentionedUserIds(msg any) ([]uuid.UUID, error) {
+	err := checkMessageID(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	_, isServerMessage := msg.(*entities.ServerMessage)
+
+	membersTable, messagesTable := "dm_channel_members", "direct_messages"
+	if isServerMessage {
+		membersTable, messagesTable = "server_channel_members", "server_messages"
+	}
+
+	messageModel := entities.GetMessageModel(msg)
+	query := fmt.Sprintf(mentionedMembersQuery, getMentionTable(isServerMessage), membersTable,
+		fmt.Sprintf(mentionsUserCondition, "cm.user_id"), messagesTable)
+
+	userIds := []uuid.UUID{}
+	err = dbA.db.Raw(query, map[string]any{
+		"channel": messageModel.ChannelID,
+		"message": messageModel.ID,
+		"sender":  messageModel.SenderID,
+	}).Scan(&userIds).Error
+
+	return userIds, err
+}
+
+func (dbA *Adapter) GetOnlineChannelMemberIds(channelId uuid.UUID, isServerChannel bool, since time.Time) ([]uuid.UUID, error) {
+	membersTable := "dm_channel_members"
+	if isServerChannel {
+		membersTable = "server_channel_members"
+	}
+
+	userIds := []uuid.UUID{}
+	err := dbA.db.Table(membersTable+" AS cm").
+		Where("cm.channel_id = ? AND EXISTS (SELECT 1 FROM user_presences AS p WHERE p.user_id = cm.user_id AND p.heartbeat_at >= ? AND p.status <> ?)",
+			channelId, since, entities.OFFLINE_STATUS).
+		Pluck("cm.user_id", &userIds).Error
+
+	return userIds, err
+}
+
+func getMentionTable(isServerMessage bool) string {
+	if isServerMessage {
+		return "server_message_mentions"
+	}
+
+	return "direct_message_mentions"
+}
+
+func mentionsUser(isServerMessage bool) string {
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM %s AS mn WHERE mn.message_id = m.id AND (%s))",
+		getMentionTable(isServerMessage), fmt.Sprintf(mentionsUserCondition, "@user"))
+}
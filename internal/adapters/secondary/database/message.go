@@ -2,17 +2,53 @@ package database
 
 import (
 	"errors"
+	"fmt"
 	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/ports"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
 )
 
-func (dbA *Adapter) CreateMessage(msg any) error {
+const NONCE_INDEX = "sender_nonce"
+
+func (dbA *Adapter) CreateMessage(msg any, nonceSince time.Time) error {
 	err := addMessageID(msg)
 	if err != nil {
 		return err
 	}
 
-	return dbA.db.Create(msg).Error
+	return dbA.db.Transaction(func(tx *gorm.DB) error {
+		messageModel := entities.GetMessageModel(msg)
+
+		if messageModel.Nonce != nil {
+			err := tx.Unscoped().Model(newMessageOfType(msg)).
+				Where("sender_id = ? AND nonce = ? AND sent_at <= ?", messageModel.SenderID, *messageModel.Nonce, nonceSince).
+				UpdateColumn("nonce", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tx.Create(msg).Error
+		if isUniqueViolation(err, NONCE_INDEX) {
+			return fmt.Errorf("%w: a message with this nonce already exists", ports.ErrConflict)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if messageModel.ParentID == nil {
+			return nil
+		}
+
+		return tx.Model(newMessageOfType(msg)).Where("id = ?", *messageModel.ParentID).
+			UpdateColumns(map[string]any{
+				"reply_count":   gorm.Expr("reply_count + 1"),
+				"last_reply_at": messageModel.SentAt,
+			}).Error
+	})
 }
 
 func (dbA *Adapter) GetMessage(msg any) error {
@@ -21,7 +57,7 @@ func (dbA *Adapter) GetMessage(msg any) error {
 		return err
 	}
 
-	return dbA.db.First(msg).Error
+	return dbA.db.Preload("Mentions").First(msg).Error
 }
 
 func (dbA *Adapter) UpdateMessage(msg any) error {
@@ -30,7 +66,32 @@ func (dbA *Adapter) UpdateMessage(msg any) error {
 		return err
 	}
 
-	return dbA.db.Model(msg).Select("content", "attachment").Updates(msg).Error
+	return dbA.db.Transaction(func(tx *gorm.DB) error {
+		currentMessage := newMessageOfType(msg)
+		err := tx.First(currentMessage, "id = ?", entities.GetMessageModel(msg).ID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Create(newRevision(currentMessage)).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(msg).Select("content", "attachment").Updates(msg).Error
+	})
+}
+
+func (dbA *Adapter) GetMessageRevisions(revisions any, messageId uuid.UUID, offset, limit int) error {
+	switch revisions.(type) {
+	case *[]entities.ServerMessageRevision:
+	case *[]entities.DirectMessageRevision:
+	default:
+		return errors.New("revisions must be of type *[]ServerMessageRevision or *[]DirectMessageRevision")
+	}
+
+	return dbA.db.Offset(offset).Limit(limit).Order("edited_at DESC").
+		Find(revisions, "message_id = ?", messageId).Error
 }
 
 func (dbA *Adapter) DeleteMessage(msg any) error {
@@ -39,7 +100,123 @@ func (dbA *Adapter) DeleteMessage(msg any) error {
 		return err
 	}
 
-	return dbA.db.Delete(msg).Error
+	return dbA.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.First(msg).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Delete(msg).Error
+		if err != nil {
+			return err
+		}
+
+		messageModel := entities.GetMessageModel(msg)
+		for _, dependent := range newMessageDependents(msg) {
+			err = tx.Where("message_id = ?", messageModel.ID).Delete(dependent).Error
+			if err != nil {
+				return err
+			}
+		}
+		if messageModel.ParentID == nil {
+			return nil
+		}
+
+		return tx.Model(newMessageOfType(msg)).Where("id = ? AND reply_count > 0", *messageModel.ParentID).
+			UpdateColumn("reply_count", gorm.Expr("reply_count - 1")).Error
+	})
+}
+
+func (dbA *Adapter) GetMessageReplies(replies any, parentId uuid.UUID, offset, limit int) error {
+	err := validateChannelMessageType(replies)
+	if err != nil {
+		return err
+	}
+
+	return dbA.db.Preload("Mentions").Offset(offset).Limit(limit).Order("sent_at").
+		Find(replies, "parent_id = ?", parentId).Error
+}
+
+func newRevision(msg any) any {
+	messageModel := entities.GetMessageModel(msg)
+	revision := entities.Revision{
+		ID:         uuid.New(),
+		MessageID:  messageModel.ID,
+		Content:    messageModel.Content,
+		Attachment: messageModel.Attachment,
+	}
+
+	if _, ok := msg.(*entities.ServerMessage); ok {
+		return &entities.ServerMessageRevision{Revision: revision}
+	}
+
+	return &entities.DirectMessageRevision{Revision: revision}
+}
+
+func (dbA *Adapter) FindMessageByNonce(msg any, senderId uuid.UUID, nonce string, since time.Time) (bool, error) {
+	err := validateMessageType(msg)
+	if err != nil {
+		return false, err
+	}
+
+	result := dbA.db.Preload("Mentions").Where("sender_id = ? AND nonce = ? AND sent_at > ?", senderId, nonce, since).
+		Limit(1).Find(msg)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (dbA *Adapter) migrateMessageNonces() error {
+	migrator := dbA.db.Migrator()
+
+	for _, msg := range []any{&entities.ServerMessage{}, &entities.DirectMessage{}} {
+		if !migrator.HasColumn(msg, "nonce") {
+			continue
+		}
+
+		statement := &gorm.Statement{DB: dbA.db}
+		err := statement.Parse(msg)
+		if err != nil {
+			return err
+		}
+
+		table := statement.Schema.Table
+		if migrator.HasIndex(msg, fmt.Sprintf("idx_%s_%s", table, NONCE_INDEX)) {
+			continue
+		}
+
+		err = dbA.db.Exec(fmt.Sprintf(`UPDATE %s SET nonce = NULL WHERE nonce IS NOT NULL AND id NOT IN (
+			SELECT DISTINCT ON (sender_id, nonce) id FROM %s WHERE nonce IS NOT NULL ORDER BY sender_id, nonce, sent_at)`,
+			table, table)).Error
+		if err != nil {
+			return err
+		}
+
+		err = dbA.db.Exec(fmt.Sprintf("DROP INDEX IF EXISTS idx_%s_nonce", table)).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newMessageDependents(msg any) []any {
+	if _, ok := msg.(*entities.ServerMessage); ok {
+		return []any{&entities.ServerMessageRevision{}, &entities.ServerMessageReaction{}}
+	}
+
+	return []any{&entities.DirectMessageRevision{}, &entities.DirectMessageReaction{}}
+}
+
+func newMessageOfType(msg any) any {
+	switch msg.(type) {
+	case *entities.ServerMessage:
+		return &entities.ServerMessage{}
+	case *entities.DirectMessage:
+		return &entities.DirectMessage{}
+	}
+
+	return nil
 }
 
 func addMessageID(msg any) error {
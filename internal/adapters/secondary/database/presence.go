@@ -0,0 +1,65 @@
+package database
+
+import (
+	"slices"
+	"time"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (dbA *Adapter) SetUserPresence(presence *entities.UserPresence) error {
+	return dbA.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(presence).Error
+}
+
+func (dbA *Adapter) RemoveUserPresence(instanceId, userId uuid.UUID) error {
+	return dbA.db.Delete(&entities.UserPresence{}, "instance_id = ? AND user_id = ?", instanceId, userId).Error
+}
+
+func (dbA *Adapter) GetUserPresences(userId uuid.UUID, since time.Time) (*[]entities.UserPresence, error) {
+	presences := &[]entities.UserPresence{}
+	err := dbA.db.Find(presences, "user_id = ? AND heartbeat_at >= ?", userId, since).Error
+
+	return presences, err
+}
+
+func (dbA *Adapter) RefreshUserPresences(instanceId uuid.UUID, presences *[]entities.UserPresence, heartbeatAt, since time.Time) (*[]uuid.UUID, error) {
+	userIds := &[]uuid.UUID{}
+
+	err := dbA.db.Transaction(func(tx *gorm.DB) error {
+		if len(*presences) > 0 {
+			err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(presences).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		removed := &[]entities.UserPresence{}
+		err := tx.Clauses(clause.Returning{Columns: []clause.Column{{Name: "user_id"}}}).
+			Where("(instance_id = ? AND heartbeat_at < ?) OR heartbeat_at < ?", instanceId, heartbeatAt, since).
+			Delete(removed).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&entities.User{}).
+			Where("status <> ? AND NOT EXISTS (SELECT 1 FROM user_presences WHERE user_presences.user_id = users.id AND user_presences.heartbeat_at >= ?)",
+				entities.OFFLINE_STATUS, since).
+			Pluck("id", userIds).Error
+		if err != nil {
+			return err
+		}
+
+		for _, presence := range *removed {
+			if !slices.Contains(*userIds, presence.UserID) {
+				*userIds = append(*userIds, presence.UserID)
+			}
+		}
+
+		return nil
+	})
+
+	return userIds, err
+}
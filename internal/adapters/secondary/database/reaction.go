@@ -0,0 +1,56 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+func (dbA *Adapter) AddReaction(reaction any) error {
+	err := validateReactionType(reaction)
+	if err != nil {
+		return err
+	}
+
+	return dbA.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error
+}
+
+func (dbA *Adapter) RemoveReaction(reaction any) error {
+	err := validateReactionType(reaction)
+	if err != nil {
+		return err
+	}
+
+	return dbA.db.Delete(reaction).Error
+}
+
+func (dbA *Adapter) GetReactionCounts(reaction any, messageIds []uuid.UUID) (*[]entities.ReactionCount, error) {
+	err := validateReactionType(reaction)
+	if err != nil {
+		return nil, err
+	}
+
+	reactionCounts := &[]entities.ReactionCount{}
+	if len(messageIds) == 0 {
+		return reactionCounts, nil
+	}
+
+	err = dbA.db.Model(reaction).Select("message_id, emoji, COUNT(*) AS count").
+		Where("message_id IN ?", messageIds).Group("message_id, emoji").
+		Order("MIN(created_at)").Scan(reactionCounts).Error
+
+	return reactionCounts, err
+}
+
+func validateReactionType(reaction any) error {
+	switch reaction.(type) {
+	case *entities.ServerMessageReaction:
+	case *entities.DirectMessageReaction:
+	default:
+		return errors.New("reaction must be of type *ServerMessageReaction or *DirectMessageReaction")
+	}
+
+	return nil
+}
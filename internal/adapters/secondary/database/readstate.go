@@ -0,0 +1,72 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+const unreadCountsQuery = `SELECT %s AS id, COUNT(*) AS unread_count,
+	COUNT(*) FILTER (WHERE %s) AS mention_count
+	FROM %s AS m
+	%s
+	LEFT JOIN channel_read_states AS r ON r.channel_id = m.channel_id AND r.user_id = @user
+	WHERE %s IN @ids AND m.deleted_at IS NULL AND m.parent_id IS NULL AND m.sender_id <> @user
+	AND (r.last_read_at IS NULL OR m.sent_at > r.last_read_at)
+	GROUP BY %s`
+
+const readStateAdvances = `channel_read_states.last_read_message_id IS NULL OR
+	(channel_read_states.last_read_at, channel_read_states.last_read_message_id) <
+	(excluded.last_read_at, excluded.last_read_message_id)`
+
+func (dbA *Adapter) SetReadState(readState *entities.ChannelReadState) (bool, error) {
+	result := dbA.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "channel_id"}, {Name: "user_id"}},
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: readStateAdvances}}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_read_message_id", "last_read_at", "updated_at"}),
+	}).Create(readState)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (dbA *Adapter) GetReadState(readState *entities.ChannelReadState) error {
+	return dbA.db.First(readState).Error
+}
+
+func (dbA *Adapter) GetUnreadCounts(userId uuid.UUID, channelIds []uuid.UUID, isServerChannel bool) (*[]entities.UnreadCount, error) {
+	table := "direct_messages"
+	if isServerChannel {
+		table = "server_messages"
+	}
+
+	query := fmt.Sprintf(unreadCountsQuery, "m.channel_id", mentionsUser(isServerChannel), table, "",
+		"m.channel_id", "m.channel_id")
+
+	return dbA.getUnreadCounts(query, userId, channelIds)
+}
+
+func (dbA *Adapter) GetServerUnreadCounts(userId uuid.UUID, serverIds []uuid.UUID) (*[]entities.UnreadCount, error) {
+	joins := `JOIN server_channels AS c ON c.id = m.channel_id
+	JOIN server_channel_members AS cm ON cm.channel_id = m.channel_id AND cm.user_id = @user`
+
+	query := fmt.Sprintf(unreadCountsQuery, "c.server_id", mentionsUser(true), "server_messages", joins,
+		"c.server_id", "c.server_id")
+
+	return dbA.getUnreadCounts(query, userId, serverIds)
+}
+
+func (dbA *Adapter) getUnreadCounts(query string, userId uuid.UUID, ids []uuid.UUID) (*[]entities.UnreadCount, error) {
+	unreadCounts := &[]entities.UnreadCount{}
+	if len(ids) == 0 {
+		return unreadCounts, nil
+	}
+
+	err := dbA.db.Raw(query, map[string]any{
+		"user": userId,
+		"ids":  ids,
+	}).Scan(unreadCounts).Error
+
+	return unreadCounts, err
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/ports"
+	"github.com/google/uuid"
+)
+
+func (dbA *Adapter) CreateRole(role *entities.ServerRole) error {
+	role.ID = uuid.New()
+
+	return dbA.db.Create(role).Error
+}
+
+func (dbA *Adapter) GetRole(id uuid.UUID) (*entities.ServerRole, error) {
+	role := &entities.ServerRole{ID: id}
+	err := dbA.db.First(role).Error
+
+	return role, err
+}
+
+func (dbA *Adapter) GetServerRoles(serverId uuid.UUID, offset, limit int) (*[]entities.ServerRole, error) {
+	roles := &[]entities.ServerRole{}
+	err := dbA.db.Offset(offset).Limit(limit).Order("position DESC").
+		Find(roles, "server_id = ?", serverId).Error
+
+	return roles, err
+}
+
+func (dbA *Adapter) UpdateRole(role *entities.ServerRole) error {
+	if role.ID == uuid.Nil {
+		return errors.New("primary key must be specified")
+	}
+
+	return dbA.db.Model(role).Select("name", "permissions", "position").Updates(role).Error
+}
+
+func (dbA *Adapter) DeleteRole(id uuid.UUID) error {
+	role := &entities.ServerRole{ID: id}
+
+	return dbA.db.Delete(role).Error
+}
+
+func (dbA *Adapter) AddMemberRole(memberRole *entities.ServerMemberRole) error {
+	err := dbA.db.Create(memberRole).Error
+	if isUniqueViolation(err, "_pkey") {
+		return fmt.Errorf("%w: member already has the role", ports.ErrConflict)
+	}
+
+	return err
+}
+
+func (dbA *Adapter) RemoveMemberRole(memberRole *entities.ServerMemberRole) error {
+	result := dbA.db.Delete(memberRole)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: member does not have the role", ports.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (dbA *Adapter) GetServerMemberRoles(serverId, userId uuid.UUID) (*[]entities.ServerRole, error) {
+	memberRoles := &[]entities.ServerMemberRole{}
+	err := dbA.db.Select("role_id").
+		Find(memberRoles, "server_id = ? AND user_id = ?", serverId, userId).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(*memberRoles))
+	for idx, obj := range *memberRoles {
+		ids[idx] = obj.RoleID
+	}
+
+	roles := &[]entities.ServerRole{}
+	if len(ids) == 0 {
+		return roles, nil
+	}
+
+	err = dbA.db.Where("id IN ?", ids).Find(roles).Error
+
+	return roles, err
+}
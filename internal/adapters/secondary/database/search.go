@@ -0,0 +1,138 @@
+package database
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+)
+
+// matches are delimited with control characters so the snippet can be HTML escaped before marking them
+const (
+	SNIPPET_START_SEL = "\x02"
+	SNIPPET_STOP_SEL  = "\x03"
+)
+
+const searchHeadlineOptions = "StartSel=" + SNIPPET_START_SEL + ", StopSel=" + SNIPPET_STOP_SEL +
+	", MaxFragments=2, MaxWords=20, MinWords=5"
+
+var snippetMarks = strings.NewReplacer(SNIPPET_START_SEL, "<mark>", SNIPPET_STOP_SEL, "</mark>")
+
+const serverMessagesSearchQuery = `SELECT m.id, m.channel_id, c.server_id, m.sender_id, m.parent_id, m.content,
+	m.attachment, m.sent_at, ts_headline('english', translate(m.content, @selectors, ''), query, @headline) AS snippet,
+	ts_rank(m.search_vector, query) AS rank
+	FROM server_messages AS m
+	CROSS JOIN websearch_to_tsquery('english', @query) AS query
+	JOIN server_channels AS c ON c.id = m.channel_id
+	WHERE m.search_vector @@ query AND m.deleted_at IS NULL AND m.channel_id IN @channels%s`
+
+const directMessagesSearchQuery = `SELECT m.id, m.channel_id, NULL::uuid AS server_id, m.sender_id, m.parent_id, m.content,
+	m.attachment, m.sent_at, ts_headline('english', translate(m.content, @selectors, ''), query, @headline) AS snippet,
+	ts_rank(m.search_vector, query) AS rank
+	FROM direct_messages AS m
+	CROSS JOIN websearch_to_tsquery('english', @query) AS query
+	JOIN dm_channel_members AS cm ON cm.channel_id = m.channel_id AND cm.user_id = @user
+	WHERE m.search_vector @@ query AND m.deleted_at IS NULL%s`
+
+func (dbA *Adapter) migrateMessageSearch() error {
+	for _, table := range []string{"server_messages", "direct_messages"} {
+		err := dbA.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (to_tsvector('english', coalesce(content, ''))) STORED`, table)).Error
+		if err != nil {
+			return err
+		}
+
+		err = dbA.db.Exec(fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_search_vector ON %s USING GIN (search_vector)`,
+			table, table)).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (dbA *Adapter) SearchMessages(search *entities.MessageSearch) (*[]entities.MessageSearchResult, error) {
+	params := map[string]any{
+		"query":     search.Query,
+		"user":      search.UserID,
+		"headline":  searchHeadlineOptions,
+		"selectors": SNIPPET_START_SEL + SNIPPET_STOP_SEL,
+		"channels":  search.ChannelIDs,
+		"limit":     search.Limit,
+		"offset":    search.Offset,
+	}
+
+	filters := ""
+	if search.SenderID != nil {
+		filters += " AND m.sender_id = @sender"
+		params["sender"] = *search.SenderID
+	}
+
+	if search.ChannelID != nil {
+		filters += " AND m.channel_id = @channel"
+		params["channel"] = *search.ChannelID
+	}
+
+	if search.From != nil {
+		filters += " AND m.sent_at >= @from"
+		params["from"] = *search.From
+	}
+
+	if search.To != nil {
+		filters += " AND m.sent_at < @to"
+		params["to"] = *search.To
+	}
+
+	if search.HasAttachment != nil {
+		if *search.HasAttachment {
+			filters += " AND coalesce(m.attachment, '') <> ''"
+		} else {
+			filters += " AND coalesce(m.attachment, '') = ''"
+		}
+	}
+
+	serverFilters, directFilters := filters, filters
+	if search.MentionsMe {
+		serverFilters += " AND " + mentionsUser(true)
+		directFilters += " AND " + mentionsUser(false)
+	}
+
+	if search.ServerID != nil {
+		serverFilters += " AND c.server_id = @server"
+		params["server"] = *search.ServerID
+	}
+
+	queries := []string{}
+	if len(search.ChannelIDs) > 0 {
+		queries = append(queries, fmt.Sprintf(serverMessagesSearchQuery, serverFilters))
+	}
+
+	if search.ServerID == nil {
+		queries = append(queries, fmt.Sprintf(directMessagesSearchQuery, directFilters))
+	}
+
+	results := &[]entities.MessageSearchResult{}
+	if len(queries) == 0 {
+		return results, nil
+	}
+
+	query := fmt.Sprintf("SELECT * FROM (%s) AS results ORDER BY rank DESC, sent_at DESC LIMIT @limit OFFSET @offset",
+		strings.Join(queries, " UNION ALL "))
+
+	err := dbA.db.Raw(query, params).Scan(results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range *results {
+		(*results)[idx].Snippet = formatSnippet((*results)[idx].Snippet)
+	}
+
+	return results, nil
+}
+
+func formatSnippet(snippet string) string {
+	return snippetMarks.Replace(html.EscapeString(snippet))
+}
@@ -0,0 +1,29 @@
+package database
+
+import "testing"
+
+func TestFormatSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{"plain text", "hello world", "hello world"},
+		{"matched term", "say \x02hello\x03 world", "say <mark>hello</mark> world"},
+		{
+			"markup in content",
+			"<img src=x onerror=alert(1)> \x02hello\x03 & <mark>bye</mark>",
+			"&lt;img src=x onerror=alert(1)&gt; <mark>hello</mark> &amp; &lt;mark&gt;bye&lt;/mark&gt;",
+		},
+		{"quotes", "\"\x02a\x03\" 'b'", "&#34;<mark>a</mark>&#34; &#39;b&#39;"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := formatSnippet(test.snippet)
+			if got != test.want {
+				t.Errorf("formatSnippet(%q) = %q, want %q", test.snippet, got, test.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,13 @@ package database
 
 import (
 	"errors"
+	"fmt"
+
 	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/ports"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func (dbA *Adapter) CreateServer(server *entities.Server) error {
@@ -26,9 +31,11 @@ func (dbA *Adapter) GetServerByName(name string) (*entities.Server, error) {
 	return server, err
 }
 
-func (dbA *Adapter) GetAllServers(offset, limit int) (*[]entities.Server, error) {
+func (dbA *Adapter) GetAllServers(userId uuid.UUID, offset, limit int) (*[]entities.Server, error) {
 	server := &[]entities.Server{}
-	err := dbA.db.Offset(offset).Limit(limit).Find(server).Error
+	err := dbA.db.Offset(offset).Limit(limit).
+		Where("id IN (?)", dbA.db.Model(&entities.ServerMember{}).Select("server_id").Where("user_id = ?", userId)).
+		Find(server).Error
 
 	return server, err
 }
@@ -61,8 +68,59 @@ func (dbA *Adapter) GetServerMembers(serverId uuid.UUID, offset, limit int) (*[]
 	return users, err
 }
 
-func (dbA *Adapter) AddServerMember(member *entities.ServerMember) error {
-	return dbA.db.Create(member).Error
+func (dbA *Adapter) AddServerMember(member *entities.ServerMember, roleId *uuid.UUID) (*[]uuid.UUID, error) {
+	channelIds := &[]uuid.UUID{}
+
+	err := dbA.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		channelIds, err = joinServer(tx, member, roleId)
+
+		return err
+	})
+
+	return channelIds, err
+}
+
+func joinServer(tx *gorm.DB, member *entities.ServerMember, roleId *uuid.UUID) (*[]uuid.UUID, error) {
+	channelIds := &[]uuid.UUID{}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(member)
+	if result.Error != nil {
+		return channelIds, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return channelIds, fmt.Errorf("%w: user is already a member of the server", ports.ErrConflict)
+	}
+
+	if roleId != nil {
+		err := tx.Create(&entities.ServerMemberRole{
+			ServerID: member.ServerID,
+			UserID:   member.UserID,
+			RoleID:   *roleId,
+		}).Error
+		if err != nil {
+			return channelIds, err
+		}
+	}
+
+	err := tx.Model(&entities.ServerChannel{}).
+		Where("server_id = ? AND mode = ?", member.ServerID, entities.PUBLIC_CHANNEL).
+		Pluck("id", channelIds).Error
+	if err != nil || len(*channelIds) == 0 {
+		return channelIds, err
+	}
+
+	channelMembers := make([]entities.ServerChannelMember, len(*channelIds))
+	for idx, channelId := range *channelIds {
+		channelMembers[idx] = entities.ServerChannelMember{
+			ChannelID: channelId,
+			ServerID:  member.ServerID,
+			UserID:    member.UserID,
+		}
+	}
+
+	return channelIds, tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&channelMembers).Error
 }
 
 func (dbA *Adapter) RemoveServerMember(serverId, userId uuid.UUID) error {
@@ -94,6 +152,23 @@ func (dbA *Adapter) GetServerChannels(serverId, userId uuid.UUID, offset, limit
 	return channels, err
 }
 
+func (dbA *Adapter) GetServerChannelIds(serverId uuid.UUID, mode string) (*[]uuid.UUID, error) {
+	query := dbA.db.Select("id").Where("server_id = ?", serverId)
+	if mode != "" {
+		query = query.Where("mode = ?", mode)
+	}
+
+	channels := &[]entities.ServerChannel{}
+	err := query.Find(channels).Error
+
+	ids := make([]uuid.UUID, len(*channels))
+	for idx, channel := range *channels {
+		ids[idx] = channel.ID
+	}
+
+	return &ids, err
+}
+
 func (dbA *Adapter) DeleteServer(id uuid.UUID) error {
 	server := &entities.Server{ID: id}
 
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/google/uuid"
+)
+
+func (dbA *Adapter) CreateSession(session *entities.Session) error {
+	if session.ID == uuid.Nil {
+		session.ID = uuid.New()
+	}
+
+	return dbA.db.Create(session).Error
+}
+
+func (dbA *Adapter) GetSession(id uuid.UUID) (*entities.Session, error) {
+	session := &entities.Session{ID: id}
+	err := dbA.db.First(session).Error
+
+	return session, err
+}
+
+func (dbA *Adapter) RotateSession(session *entities.Session, refreshTokenHash string) (bool, error) {
+	if session.ID == uuid.Nil {
+		return false, errors.New("primary key must be specified")
+	}
+
+	result := dbA.db.Model(session).Where("refresh_token_hash = ? AND revoked_at IS NULL", refreshTokenHash).
+		Select("refresh_token_hash", "expires_at").Updates(session)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (dbA *Adapter) RevokeSession(id uuid.UUID) error {
+	return dbA.db.Model(&entities.Session{ID: id}).
+		Where("revoked_at IS NULL").Update("revoked_at", time.Now()).Error
+}
+
+func (dbA *Adapter) RevokeUserSessions(userId uuid.UUID) (*[]uuid.UUID, error) {
+	sessions := &[]entities.Session{}
+	err := dbA.db.Select("id").
+		Find(sessions, "user_id = ? AND revoked_at IS NULL", userId).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(*sessions))
+	for idx, session := range *sessions {
+		ids[idx] = session.ID
+	}
+
+	if len(ids) == 0 {
+		return &ids, nil
+	}
+
+	err = dbA.db.Model(&entities.Session{}).Where("id IN ?", ids).
+		Update("revoked_at", time.Now()).Error
+
+	return &ids, err
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/critch-app/critch-backend/internal/application/core/entities"
 	"github.com/google/uuid"
+	"time"
 )
 
 func (dbA *Adapter) CreateUser(user *entities.User) error {
@@ -38,7 +39,16 @@ func (dbA *Adapter) UpdateUser(user *entities.User) error {
 		return errors.New("primary key must be specified")
 	}
 
-	return dbA.db.Model(user).Omit("ID", "CreatedAt").Updates(user).Error
+	return dbA.db.Model(user).Omit("ID", "CreatedAt", "Status", "LastSeen").Updates(user).Error
+}
+
+func (dbA *Adapter) UpdateUserPresence(userId uuid.UUID, status string, lastSeen time.Time) error {
+	user := &entities.User{ID: userId}
+
+	return dbA.db.Model(user).Updates(map[string]any{
+		"status":    status,
+		"last_seen": lastSeen,
+	}).Error
 }
 
 func (dbA *Adapter) GetUserServers(userId uuid.UUID, offset, limit int) (*[]entities.Server, error) {
@@ -81,26 +91,16 @@ func (dbA *Adapter) GetUserDMChannels(userId uuid.UUID, offset, limit int) (*[]e
 	return channels, err
 }
 
-func (dbA *Adapter) GetUserChannelIds(userId uuid.UUID) (*[]uuid.UUID, error) {
-	dmChannels := &[]entities.DMChannelMember{}
-	err := dbA.db.Select("channel_id").
-		Find(dmChannels, "user_id = ?", userId).Error
-
-	serverChannels := &[]entities.ServerChannelMember{}
-	err = dbA.db.Select("channel_id").
-		Find(serverChannels, "user_id = ?", userId).Error
-
-	ids := make([]uuid.UUID, len(*dmChannels)+len(*serverChannels))
-	for idx, channel := range *dmChannels {
-		ids[idx] = channel.ChannelID
+func (dbA *Adapter) GetUserChannelIds(userId uuid.UUID, isServerChannel bool) (*[]uuid.UUID, error) {
+	var channelMember any = &entities.DMChannelMember{}
+	if isServerChannel {
+		channelMember = &entities.ServerChannelMember{}
 	}
 
-	offset := len(*dmChannels)
-	for idx, channel := range *serverChannels {
-		ids[offset+idx] = channel.ChannelID
-	}
+	ids := &[]uuid.UUID{}
+	err := dbA.db.Model(channelMember).Where("user_id = ?", userId).Pluck("channel_id", ids).Error
 
-	return &ids, err
+	return ids, err
 }
 
 func (dbA *Adapter) GetUserServerIds(userId uuid.UUID) (*[]uuid.UUID, error) {
@@ -1,18 +1,40 @@
 package application
 
 import (
+	"errors"
+	"time"
+
 	"github.com/critch-app/critch-backend/internal/application/core/msgsrvc"
 	"github.com/critch-app/critch-backend/internal/ports"
+	"github.com/google/uuid"
+)
+
+const (
+	MAX_NONCE_LENGTH   = 64
+	NONCE_DEDUP_WINDOW = 10 * time.Minute
 )
 
+var ErrValidation = errors.New("validation failed")
+
 type App struct {
 	db               ports.DB
 	messagingService *msgsrvc.MessagingService
+	memberships      *membershipCache
+	operators        map[uuid.UUID]bool
+	instanceId       uuid.UUID
 }
 
-func NewApp(dbAdapter ports.DB, messagingService *msgsrvc.MessagingService) *App {
+func NewApp(dbAdapter ports.DB, messagingService *msgsrvc.MessagingService, operatorIds []uuid.UUID) *App {
+	operators := make(map[uuid.UUID]bool, len(operatorIds))
+	for _, operatorId := range operatorIds {
+		operators[operatorId] = true
+	}
+
 	return &App{
 		db:               dbAdapter,
 		messagingService: messagingService,
+		memberships:      newMembershipCache(),
+		operators:        operators,
+		instanceId:       uuid.New(),
 	}
 }
@@ -0,0 +1,423 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/ports"
+	"github.com/google/uuid"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+const (
+	OWNER_ROLE  = "owner"
+	ADMIN_ROLE  = "admin"
+	MEMBER_ROLE = "member"
+)
+
+const (
+	VIEW_SERVER     = "view_server"
+	MANAGE_SERVER   = "manage_server"
+	DELETE_SERVER   = "delete_server"
+	MANAGE_MEMBERS  = "manage_members"
+	MANAGE_CHANNELS = "manage_channels"
+	CREATE_INVITE   = "create_invite"
+	VIEW_CHANNEL    = "view_channel"
+	MANAGE_CHANNEL  = "manage_channel"
+	SEND_MESSAGE    = "send_message"
+	ADD_MEMBER      = "add_member"
+	REMOVE_MEMBER   = "remove_member"
+	VIEW_MESSAGE    = "view_message"
+	EDIT_MESSAGE    = "edit_message"
+	DELETE_MESSAGE  = "delete_message"
+)
+
+var rolePermissions = map[string]entities.Permissions{
+	OWNER_ROLE:  entities.ALL_PERMISSIONS,
+	ADMIN_ROLE:  entities.ALL_PERMISSIONS,
+	MEMBER_ROLE: entities.DEFAULT_PERMISSIONS,
+}
+
+var actionPermissions = map[string]entities.Permissions{
+	VIEW_SERVER:     entities.NO_PERMISSIONS,
+	MANAGE_SERVER:   entities.MANAGE_SERVER,
+	MANAGE_MEMBERS:  entities.MANAGE_MEMBERS,
+	MANAGE_CHANNELS: entities.MANAGE_CHANNELS,
+	CREATE_INVITE:   entities.CREATE_INVITES,
+}
+
+type serverMember struct {
+	role        string
+	roleIds     []uuid.UUID
+	permissions entities.Permissions
+	rank        int
+}
+
+func resolveServerMember(role string, roles []entities.ServerRole) *serverMember {
+	member := &serverMember{
+		role:        role,
+		permissions: rolePermissions[role],
+	}
+
+	switch role {
+	case OWNER_ROLE:
+		member.rank = math.MaxInt
+	case ADMIN_ROLE:
+		member.rank = math.MaxInt - 1
+	}
+
+	for _, customRole := range roles {
+		member.roleIds = append(member.roleIds, customRole.ID)
+		member.permissions |= customRole.Permissions
+		if customRole.Position > member.rank {
+			member.rank = customRole.Position
+		}
+	}
+
+	return member
+}
+
+func canPerformServerAction(member *serverMember, action string) bool {
+	if action == DELETE_SERVER {
+		return member.role == OWNER_ROLE
+	}
+
+	permission, ok := actionPermissions[action]
+	return ok && member.permissions.Has(permission)
+}
+
+func canRemoveServerMember(actorId, targetId uuid.UUID, actor, target *serverMember) bool {
+	if target.role == OWNER_ROLE {
+		return false
+	}
+
+	if actorId == targetId {
+		return true
+	}
+
+	return actor.permissions.Has(entities.MANAGE_MEMBERS) && actor.rank > target.rank
+}
+
+func canManageRole(actor *serverMember, permissions entities.Permissions, position int) bool {
+	return actor.permissions.Has(entities.MANAGE_ROLES) &&
+		actor.permissions.Has(permissions) &&
+		position < actor.rank
+}
+
+func resolveChannelPermissions(userId uuid.UUID, member *serverMember, mode string, isChannelMember bool, overrides []entities.ChannelPermissionOverride) entities.Permissions {
+	permissions := member.permissions
+	if permissions.Has(entities.MANAGE_CHANNELS) {
+		return permissions
+	}
+
+	switch mode {
+	case entities.PRIVATE_CHANNEL:
+		if !isChannelMember {
+			permissions &^= entities.VIEW_CHANNELS
+		}
+	case entities.READ_ONLY_CHANNEL:
+		permissions &^= entities.SEND_MESSAGES
+	}
+
+	var roleAllow, roleDeny entities.Permissions
+	for _, override := range overrides {
+		if override.TargetType == entities.ROLE_OVERRIDE && slices.Contains(member.roleIds, override.TargetID) {
+			roleAllow |= override.Allow
+			roleDeny |= override.Deny
+		}
+	}
+
+	permissions = (permissions &^ roleDeny) | roleAllow
+
+	for _, override := range overrides {
+		if override.TargetType == entities.USER_OVERRIDE && override.TargetID == userId {
+			permissions = (permissions &^ override.Deny) | override.Allow
+		}
+	}
+
+	if !permissions.Has(entities.VIEW_CHANNELS) {
+		permissions &^= entities.SEND_MESSAGES
+	}
+
+	return permissions
+}
+
+func canAccessChannel(action string, actorId, targetId uuid.UUID, mode string, permissions entities.Permissions) bool {
+	switch action {
+	case VIEW_CHANNEL:
+		return permissions.Has(entities.VIEW_CHANNELS)
+	case SEND_MESSAGE:
+		return permissions.Has(entities.VIEW_CHANNELS | entities.SEND_MESSAGES)
+	case MANAGE_CHANNEL:
+		return permissions.Has(entities.MANAGE_CHANNELS)
+	case ADD_MEMBER:
+		return permissions.Has(entities.MANAGE_CHANNELS) ||
+			(actorId == targetId && mode != entities.PRIVATE_CHANNEL && permissions.Has(entities.VIEW_CHANNELS))
+	case REMOVE_MEMBER:
+		return permissions.Has(entities.MANAGE_CHANNELS) || actorId == targetId
+	}
+
+	return false
+}
+
+func canAccessDMChannel(action string, actorId, targetId uuid.UUID, isChannelMember bool) bool {
+	switch action {
+	case VIEW_CHANNEL, SEND_MESSAGE, MANAGE_CHANNEL, ADD_MEMBER:
+		return isChannelMember
+	case REMOVE_MEMBER:
+		return isChannelMember && actorId == targetId
+	}
+
+	return false
+}
+
+func canModifyMessage(action string, actorId, senderId uuid.UUID, permissions entities.Permissions) bool {
+	switch action {
+	case EDIT_MESSAGE:
+		return actorId == senderId
+	case DELETE_MESSAGE:
+		return actorId == senderId || permissions.Has(entities.DELETE_MESSAGES)
+	}
+
+	return false
+}
+
+func (app *App) getServerMember(serverId, userId uuid.UUID) (*serverMember, error) {
+	role, err := app.getServerMemberRole(serverId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := app.db.GetServerMemberRoles(serverId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveServerMember(role, *roles), nil
+}
+
+func (app *App) getChannelPermissions(channel *entities.ServerChannel, userId uuid.UUID) (entities.Permissions, error) {
+	member, err := app.getServerMember(channel.ServerID, userId)
+	if err != nil {
+		return entities.NO_PERMISSIONS, err
+	}
+
+	isChannelMember := app.isChannelMember(&entities.ServerChannelMember{
+		ChannelID: channel.ID,
+		ServerID:  channel.ServerID,
+		UserID:    userId,
+	})
+
+	overrides, err := app.db.GetChannelOverrides(channel.ID)
+	if err != nil {
+		return entities.NO_PERMISSIONS, err
+	}
+
+	return resolveChannelPermissions(userId, member, channel.Mode, isChannelMember, *overrides), nil
+}
+
+func (app *App) GetServerMemberPermissions(serverId, userId uuid.UUID) (string, entities.Permissions, error) {
+	member, err := app.getServerMember(serverId, userId)
+	if err != nil {
+		return "", entities.NO_PERMISSIONS, err
+	}
+
+	return member.role, member.permissions, nil
+}
+
+func (app *App) AuthorizeUser(actorId, userId uuid.UUID) error {
+	if actorId != userId {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func (app *App) AuthorizeOperator(actorId uuid.UUID) error {
+	if !app.operators[actorId] {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func (app *App) AuthorizeServer(actorId, serverId uuid.UUID, action string) error {
+	member, err := app.getServerMember(serverId, actorId)
+	if errors.Is(err, ports.ErrNotFound) {
+		_, err = app.db.GetServer(serverId)
+		if err != nil {
+			return err
+		}
+
+		return ErrForbidden
+	}
+
+	if err != nil || !canPerformServerAction(member, action) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func (app *App) AuthorizeServerMemberRemoval(actorId, serverId, userId uuid.UUID) error {
+	actor, err := app.getServerMember(serverId, actorId)
+	if err != nil {
+		return ErrForbidden
+	}
+
+	target, err := app.getServerMember(serverId, userId)
+	if err != nil {
+		return err
+	}
+
+	if !canRemoveServerMember(actorId, userId, actor, target) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func (app *App) AuthorizeRole(actorId uuid.UUID, role *entities.ServerRole) error {
+	actor, err := app.getServerMember(role.ServerID, actorId)
+	if err != nil {
+		return ErrForbidden
+	}
+
+	if role.ID != uuid.Nil {
+		currentRole, err := app.db.GetRole(role.ID)
+		if err != nil {
+			return err
+		}
+
+		if currentRole.ServerID != role.ServerID || !canManageRole(actor, currentRole.Permissions, currentRole.Position) {
+			return ErrForbidden
+		}
+	}
+
+	if !canManageRole(actor, role.Permissions, role.Position) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func (app *App) AuthorizeChannel(actorId, channelId uuid.UUID, isServerChannel bool, action string) error {
+	return app.AuthorizeChannelMember(actorId, channelId, actorId, isServerChannel, action)
+}
+
+func (app *App) AuthorizeChannelMember(actorId, channelId, userId uuid.UUID, isServerChannel bool, action string) error {
+	if !isServerChannel {
+		isChannelMember := app.isChannelMember(&entities.DMChannelMember{
+			ChannelID: channelId,
+			UserID:    actorId,
+		})
+
+		if !canAccessDMChannel(action, actorId, userId, isChannelMember) {
+			return ErrForbidden
+		}
+
+		return nil
+	}
+
+	channel := &entities.ServerChannel{Channel: entities.Channel{ID: channelId}}
+	err := app.db.GetChannel(channel)
+	if err != nil {
+		return err
+	}
+
+	return app.authorizeServerChannelMember(actorId, userId, channel, action)
+}
+
+func (app *App) AuthorizeMessagingChannel(actorId, serverId, channelId uuid.UUID, action string) error {
+	if serverId == uuid.Nil {
+		return app.AuthorizeChannel(actorId, channelId, false, action)
+	}
+
+	channel := &entities.ServerChannel{Channel: entities.Channel{ID: channelId}}
+	err := app.db.GetChannel(channel)
+	if err != nil {
+		return err
+	}
+
+	if channel.ServerID != serverId {
+		return fmt.Errorf("%w: channel does not belong to the server", ErrValidation)
+	}
+
+	return app.authorizeServerChannelMember(actorId, actorId, channel, action)
+}
+
+func (app *App) authorizeServerChannelMember(actorId, userId uuid.UUID, channel *entities.ServerChannel, action string) error {
+	permissions, err := app.getChannelPermissions(channel, actorId)
+	if err != nil {
+		return ErrForbidden
+	}
+
+	if !canAccessChannel(action, actorId, userId, channel.Mode, permissions) {
+		return ErrForbidden
+	}
+
+	if action == ADD_MEMBER {
+		_, err = app.getServerMemberRole(channel.ServerID, userId)
+		if err != nil {
+			return fmt.Errorf("%w: user is not a member of the channel's server", ErrForbidden)
+		}
+	}
+
+	return nil
+}
+
+func (app *App) AuthorizeMessage(actorId, messageId uuid.UUID, isServerMessage bool, action string) error {
+	var (
+		message   any
+		channelId uuid.UUID
+		senderId  uuid.UUID
+	)
+
+	if isServerMessage {
+		message = &entities.ServerMessage{Message: entities.Message{ID: messageId}}
+	} else {
+		message = &entities.DirectMessage{Message: entities.Message{ID: messageId}}
+	}
+
+	err := app.db.GetMessage(message)
+	if err != nil {
+		return err
+	}
+
+	if isServerMessage {
+		channelId = message.(*entities.ServerMessage).ChannelID
+		senderId = message.(*entities.ServerMessage).SenderID
+	} else {
+		channelId = message.(*entities.DirectMessage).ChannelID
+		senderId = message.(*entities.DirectMessage).SenderID
+	}
+
+	err = app.AuthorizeChannel(actorId, channelId, isServerMessage, VIEW_CHANNEL)
+	if err != nil || action == VIEW_MESSAGE {
+		return err
+	}
+
+	permissions := entities.NO_PERMISSIONS
+	if isServerMessage {
+		channel := &entities.ServerChannel{Channel: entities.Channel{ID: channelId}}
+		err = app.db.GetChannel(channel)
+		if err != nil {
+			return err
+		}
+
+		permissions, err = app.getChannelPermissions(channel, actorId)
+		if err != nil {
+			return ErrForbidden
+		}
+	}
+
+	if !canModifyMessage(action, actorId, senderId, permissions) {
+		return ErrForbidden
+	}
+
+	return nil
+}
@@ -0,0 +1,368 @@
+package application
+
+import (
+	"math"
+	"testing"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/google/uuid"
+)
+
+func TestResolveServerMember(t *testing.T) {
+	moderatorRole := entities.ServerRole{ID: uuid.New(), Permissions: entities.MANAGE_MEMBERS, Position: 5}
+	helperRole := entities.ServerRole{ID: uuid.New(), Permissions: entities.DELETE_MESSAGES, Position: 2}
+
+	tests := []struct {
+		name            string
+		role            string
+		roles           []entities.ServerRole
+		wantPermissions entities.Permissions
+		wantRank        int
+	}{
+		{"owner", OWNER_ROLE, nil, entities.ALL_PERMISSIONS, math.MaxInt},
+		{"admin", ADMIN_ROLE, nil, entities.ALL_PERMISSIONS, math.MaxInt - 1},
+		{"member", MEMBER_ROLE, nil, entities.DEFAULT_PERMISSIONS, 0},
+		{
+			"member with custom roles",
+			MEMBER_ROLE,
+			[]entities.ServerRole{helperRole, moderatorRole},
+			entities.DEFAULT_PERMISSIONS | entities.MANAGE_MEMBERS | entities.DELETE_MESSAGES,
+			5,
+		},
+		{"admin keeps its rank above custom roles", ADMIN_ROLE, []entities.ServerRole{moderatorRole}, entities.ALL_PERMISSIONS, math.MaxInt - 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			member := resolveServerMember(test.role, test.roles)
+
+			if member.permissions != test.wantPermissions {
+				t.Errorf("permissions = %v, want %v", member.permissions.Names(), test.wantPermissions.Names())
+			}
+
+			if member.rank != test.wantRank {
+				t.Errorf("rank = %d, want %d", member.rank, test.wantRank)
+			}
+
+			if len(member.roleIds) != len(test.roles) {
+				t.Errorf("roleIds = %v, want %d ids", member.roleIds, len(test.roles))
+			}
+		})
+	}
+}
+
+func TestCanPerformServerAction(t *testing.T) {
+	owner := resolveServerMember(OWNER_ROLE, nil)
+	admin := resolveServerMember(ADMIN_ROLE, nil)
+	member := resolveServerMember(MEMBER_ROLE, nil)
+	channelManager := resolveServerMember(MEMBER_ROLE, []entities.ServerRole{
+		{ID: uuid.New(), Permissions: entities.MANAGE_CHANNELS, Position: 1},
+	})
+
+	tests := []struct {
+		name   string
+		member *serverMember
+		action string
+		want   bool
+	}{
+		{"owner deletes server", owner, DELETE_SERVER, true},
+		{"admin cannot delete server", admin, DELETE_SERVER, false},
+		{"member cannot delete server", member, DELETE_SERVER, false},
+		{"owner manages server", owner, MANAGE_SERVER, true},
+		{"admin manages server", admin, MANAGE_SERVER, true},
+		{"member cannot manage server", member, MANAGE_SERVER, false},
+		{"member views server", member, VIEW_SERVER, true},
+		{"member creates invite", member, CREATE_INVITE, true},
+		{"member cannot manage channels", member, MANAGE_CHANNELS, false},
+		{"custom role manages channels", channelManager, MANAGE_CHANNELS, true},
+		{"custom role cannot manage members", channelManager, MANAGE_MEMBERS, false},
+		{"unknown action", owner, "unknown", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := canPerformServerAction(test.member, test.action)
+			if got != test.want {
+				t.Errorf("canPerformServerAction(%s) = %v, want %v", test.action, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCanRemoveServerMember(t *testing.T) {
+	actorId, targetId := uuid.New(), uuid.New()
+
+	owner := resolveServerMember(OWNER_ROLE, nil)
+	admin := resolveServerMember(ADMIN_ROLE, nil)
+	member := resolveServerMember(MEMBER_ROLE, nil)
+	seniorModerator := resolveServerMember(MEMBER_ROLE, []entities.ServerRole{
+		{ID: uuid.New(), Permissions: entities.MANAGE_MEMBERS, Position: 5},
+	})
+	juniorModerator := resolveServerMember(MEMBER_ROLE, []entities.ServerRole{
+		{ID: uuid.New(), Permissions: entities.MANAGE_MEMBERS, Position: 3},
+	})
+	seniorMember := resolveServerMember(MEMBER_ROLE, []entities.ServerRole{
+		{ID: uuid.New(), Permissions: entities.NO_PERMISSIONS, Position: 5},
+	})
+
+	tests := []struct {
+		name     string
+		targetId uuid.UUID
+		actor    *serverMember
+		target   *serverMember
+		want     bool
+	}{
+		{"owner cannot be removed by admin", targetId, admin, owner, false},
+		{"owner cannot leave", actorId, owner, owner, false},
+		{"member leaves", actorId, member, member, true},
+		{"admin removes member", targetId, admin, member, true},
+		{"owner removes admin", targetId, owner, admin, true},
+		{"admin cannot remove admin", targetId, admin, admin, false},
+		{"member cannot remove member", targetId, member, member, false},
+		{"moderator removes lower moderator", targetId, seniorModerator, juniorModerator, true},
+		{"moderator cannot remove higher moderator", targetId, juniorModerator, seniorModerator, false},
+		{"moderator cannot remove equal rank", targetId, seniorModerator, seniorMember, false},
+		{"high rank without permission cannot remove", targetId, seniorMember, member, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := canRemoveServerMember(actorId, test.targetId, test.actor, test.target)
+			if got != test.want {
+				t.Errorf("canRemoveServerMember() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCanManageRole(t *testing.T) {
+	admin := resolveServerMember(ADMIN_ROLE, nil)
+	member := resolveServerMember(MEMBER_ROLE, nil)
+	roleManager := resolveServerMember(MEMBER_ROLE, []entities.ServerRole{
+		{ID: uuid.New(), Permissions: entities.MANAGE_ROLES | entities.MANAGE_CHANNELS, Position: 4},
+	})
+
+	tests := []struct {
+		name        string
+		actor       *serverMember
+		permissions entities.Permissions
+		position    int
+		want        bool
+	}{
+		{"admin manages any role", admin, entities.ALL_PERMISSIONS, 100, true},
+		{"member cannot manage roles", member, entities.NO_PERMISSIONS, 1, false},
+		{"manager grants held permission below its rank", roleManager, entities.MANAGE_CHANNELS, 3, true},
+		{"manager cannot grant permission it lacks", roleManager, entities.MANAGE_SERVER, 3, false},
+		{"manager cannot manage role at its rank", roleManager, entities.NO_PERMISSIONS, 4, false},
+		{"manager cannot manage role above its rank", roleManager, entities.NO_PERMISSIONS, 5, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := canManageRole(test.actor, test.permissions, test.position)
+			if got != test.want {
+				t.Errorf("canManageRole() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveChannelPermissions(t *testing.T) {
+	userId := uuid.New()
+	roleId := uuid.New()
+
+	member := resolveServerMember(MEMBER_ROLE, nil)
+	admin := resolveServerMember(ADMIN_ROLE, nil)
+	roleMember := resolveServerMember(MEMBER_ROLE, []entities.ServerRole{{ID: roleId, Position: 1}})
+	moderator := resolveServerMember(MEMBER_ROLE, []entities.ServerRole{{ID: roleId, Permissions: entities.DELETE_MESSAGES, Position: 1}})
+
+	roleOverride := func(allow, deny entities.Permissions) entities.ChannelPermissionOverride {
+		return entities.ChannelPermissionOverride{TargetType: entities.ROLE_OVERRIDE, TargetID: roleId, Allow: allow, Deny: deny}
+	}
+
+	userOverride := func(allow, deny entities.Permissions) entities.ChannelPermissionOverride {
+		return entities.ChannelPermissionOverride{TargetType: entities.USER_OVERRIDE, TargetID: userId, Allow: allow, Deny: deny}
+	}
+
+	tests := []struct {
+		name            string
+		member          *serverMember
+		mode            string
+		isChannelMember bool
+		overrides       []entities.ChannelPermissionOverride
+		want            entities.Permissions
+	}{
+		{"public channel keeps server permissions", member, entities.PUBLIC_CHANNEL, true, nil, entities.DEFAULT_PERMISSIONS},
+		{"private channel member can view", member, entities.PRIVATE_CHANNEL, true, nil, entities.DEFAULT_PERMISSIONS},
+		{
+			"private channel hides from non-members",
+			member, entities.PRIVATE_CHANNEL, false, nil,
+			entities.CREATE_INVITES,
+		},
+		{
+			"read-only channel removes send",
+			member, entities.READ_ONLY_CHANNEL, true, nil,
+			entities.CREATE_INVITES | entities.VIEW_CHANNELS,
+		},
+		{"channel managers bypass modes", admin, entities.PRIVATE_CHANNEL, false, nil, entities.ALL_PERMISSIONS},
+		{
+			"channel managers bypass overrides",
+			admin, entities.PUBLIC_CHANNEL, true,
+			[]entities.ChannelPermissionOverride{userOverride(entities.NO_PERMISSIONS, entities.VIEW_CHANNELS)},
+			entities.ALL_PERMISSIONS,
+		},
+		{
+			"role override denies send",
+			roleMember, entities.PUBLIC_CHANNEL, true,
+			[]entities.ChannelPermissionOverride{roleOverride(entities.NO_PERMISSIONS, entities.SEND_MESSAGES)},
+			entities.CREATE_INVITES | entities.VIEW_CHANNELS,
+		},
+		{
+			"role override allows send in read-only channel",
+			roleMember, entities.READ_ONLY_CHANNEL, true,
+			[]entities.ChannelPermissionOverride{roleOverride(entities.SEND_MESSAGES, entities.NO_PERMISSIONS)},
+			entities.DEFAULT_PERMISSIONS,
+		},
+		{
+			"role override for another role is ignored",
+			member, entities.PUBLIC_CHANNEL, true,
+			[]entities.ChannelPermissionOverride{roleOverride(entities.NO_PERMISSIONS, entities.SEND_MESSAGES)},
+			entities.DEFAULT_PERMISSIONS,
+		},
+		{
+			"user override wins over role override",
+			roleMember, entities.PUBLIC_CHANNEL, true,
+			[]entities.ChannelPermissionOverride{
+				roleOverride(entities.NO_PERMISSIONS, entities.SEND_MESSAGES),
+				userOverride(entities.SEND_MESSAGES, entities.NO_PERMISSIONS),
+			},
+			entities.DEFAULT_PERMISSIONS,
+		},
+		{
+			"user override grants mention everyone",
+			member, entities.PUBLIC_CHANNEL, true,
+			[]entities.ChannelPermissionOverride{userOverride(entities.MENTION_EVERYONE, entities.NO_PERMISSIONS)},
+			entities.DEFAULT_PERMISSIONS | entities.MENTION_EVERYONE,
+		},
+		{
+			"role override denies deleting messages",
+			moderator, entities.PUBLIC_CHANNEL, true,
+			[]entities.ChannelPermissionOverride{roleOverride(entities.NO_PERMISSIONS, entities.DELETE_MESSAGES)},
+			entities.DEFAULT_PERMISSIONS,
+		},
+		{
+			"losing view also removes send",
+			member, entities.PUBLIC_CHANNEL, true,
+			[]entities.ChannelPermissionOverride{userOverride(entities.NO_PERMISSIONS, entities.VIEW_CHANNELS)},
+			entities.CREATE_INVITES,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := resolveChannelPermissions(userId, test.member, test.mode, test.isChannelMember, test.overrides)
+			if got != test.want {
+				t.Errorf("resolveChannelPermissions() = %v, want %v", got.Names(), test.want.Names())
+			}
+		})
+	}
+}
+
+func TestCanAccessChannel(t *testing.T) {
+	actorId, targetId := uuid.New(), uuid.New()
+
+	viewer := entities.VIEW_CHANNELS
+	sender := entities.VIEW_CHANNELS | entities.SEND_MESSAGES
+	manager := entities.VIEW_CHANNELS | entities.MANAGE_CHANNELS
+
+	tests := []struct {
+		name        string
+		action      string
+		targetId    uuid.UUID
+		mode        string
+		permissions entities.Permissions
+		want        bool
+	}{
+		{"viewer views", VIEW_CHANNEL, actorId, entities.PUBLIC_CHANNEL, viewer, true},
+		{"hidden channel cannot be viewed", VIEW_CHANNEL, actorId, entities.PUBLIC_CHANNEL, entities.NO_PERMISSIONS, false},
+		{"sender sends", SEND_MESSAGE, actorId, entities.PUBLIC_CHANNEL, sender, true},
+		{"viewer cannot send", SEND_MESSAGE, actorId, entities.PUBLIC_CHANNEL, viewer, false},
+		{"send without view is denied", SEND_MESSAGE, actorId, entities.PUBLIC_CHANNEL, entities.SEND_MESSAGES, false},
+		{"manager manages", MANAGE_CHANNEL, actorId, entities.PUBLIC_CHANNEL, manager, true},
+		{"sender cannot manage", MANAGE_CHANNEL, actorId, entities.PUBLIC_CHANNEL, sender, false},
+		{"viewer joins public channel", ADD_MEMBER, actorId, entities.PUBLIC_CHANNEL, viewer, true},
+		{"viewer cannot join private channel", ADD_MEMBER, actorId, entities.PRIVATE_CHANNEL, viewer, false},
+		{"viewer cannot add others", ADD_MEMBER, targetId, entities.PUBLIC_CHANNEL, viewer, false},
+		{"manager adds others to private channel", ADD_MEMBER, targetId, entities.PRIVATE_CHANNEL, manager, true},
+		{"member leaves", REMOVE_MEMBER, actorId, entities.PRIVATE_CHANNEL, entities.NO_PERMISSIONS, true},
+		{"viewer cannot remove others", REMOVE_MEMBER, targetId, entities.PUBLIC_CHANNEL, viewer, false},
+		{"manager removes others", REMOVE_MEMBER, targetId, entities.PUBLIC_CHANNEL, manager, true},
+		{"unknown action", "unknown", actorId, entities.PUBLIC_CHANNEL, entities.ALL_PERMISSIONS, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := canAccessChannel(test.action, actorId, test.targetId, test.mode, test.permissions)
+			if got != test.want {
+				t.Errorf("canAccessChannel(%s) = %v, want %v", test.action, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCanAccessDMChannel(t *testing.T) {
+	actorId, targetId := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name            string
+		action          string
+		targetId        uuid.UUID
+		isChannelMember bool
+		want            bool
+	}{
+		{"member views", VIEW_CHANNEL, actorId, true, true},
+		{"member sends", SEND_MESSAGE, actorId, true, true},
+		{"non-member cannot send", SEND_MESSAGE, actorId, false, false},
+		{"member adds others", ADD_MEMBER, targetId, true, true},
+		{"member leaves", REMOVE_MEMBER, actorId, true, true},
+		{"member cannot remove others", REMOVE_MEMBER, targetId, true, false},
+		{"non-member cannot leave", REMOVE_MEMBER, actorId, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := canAccessDMChannel(test.action, actorId, test.targetId, test.isChannelMember)
+			if got != test.want {
+				t.Errorf("canAccessDMChannel(%s) = %v, want %v", test.action, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCanModifyMessage(t *testing.T) {
+	actorId, senderId := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name        string
+		action      string
+		senderId    uuid.UUID
+		permissions entities.Permissions
+		want        bool
+	}{
+		{"author edits", EDIT_MESSAGE, actorId, entities.NO_PERMISSIONS, true},
+		{"moderator cannot edit others", EDIT_MESSAGE, senderId, entities.ALL_PERMISSIONS, false},
+		{"author deletes", DELETE_MESSAGE, actorId, entities.NO_PERMISSIONS, true},
+		{"moderator deletes others", DELETE_MESSAGE, senderId, entities.DELETE_MESSAGES, true},
+		{"member cannot delete others", DELETE_MESSAGE, senderId, entities.DEFAULT_PERMISSIONS, false},
+		{"view is not a modification", VIEW_MESSAGE, actorId, entities.ALL_PERMISSIONS, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := canModifyMessage(test.action, actorId, test.senderId, test.permissions)
+			if got != test.want {
+				t.Errorf("canModifyMessage(%s) = %v, want %v", test.action, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package application
+
+import (
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/application/core/msgsrvc"
+	"github.com/google/uuid"
+)
+
+func (app *App) publishDomainEvent(event *msgsrvc.BroadcastMessage, data any) {
+	event.Type = msgsrvc.DOMAIN_EVENT
+	event.Message = map[string]any{
+		"type": event.Event,
+		"data": data,
+	}
+
+	app.messagingService.Broadcast <- event
+}
+
+func (app *App) publishChannelEvent(eventType string, channel any, userId uuid.UUID) {
+	event := &msgsrvc.BroadcastMessage{
+		Event:  eventType,
+		UserId: userId,
+	}
+
+	switch channelModel := channel.(type) {
+	case *entities.ServerChannel:
+		event.ChannelId = channelModel.ID
+		if channelModel.Mode != entities.PRIVATE_CHANNEL {
+			event.ServerId = channelModel.ServerID
+		}
+
+		app.publishDomainEvent(event, map[string]any{
+			"id":          channelModel.ID,
+			"server_id":   channelModel.ServerID,
+			"mode":        channelModel.Mode,
+			"name":        channelModel.Name,
+			"description": channelModel.Description,
+			"created_at":  channelModel.CreatedAt,
+		})
+	case *entities.DMChannel:
+		event.ChannelId = channelModel.ID
+
+		app.publishDomainEvent(event, map[string]any{
+			"id":          channelModel.ID,
+			"name":        channelModel.Name,
+			"description": channelModel.Description,
+			"created_at":  channelModel.CreatedAt,
+		})
+	}
+}
+
+func (app *App) publishMemberEvent(eventType string, serverId, userId uuid.UUID, channels []uuid.UUID, data map[string]any) {
+	data["server_id"] = serverId
+	data["user_id"] = userId
+
+	app.publishDomainEvent(&msgsrvc.BroadcastMessage{
+		Event:    eventType,
+		ServerId: serverId,
+		UserId:   userId,
+		Channels: channels,
+	}, data)
+}
@@ -1,29 +1,132 @@
 package application
 
 import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
 	"github.com/critch-app/critch-backend/internal/application/core/entities"
 	"github.com/critch-app/critch-backend/internal/application/core/msgsrvc"
+	"github.com/critch-app/critch-backend/internal/ports"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func (app *App) Login(email, password string) (string, uuid.UUID, error) {
+func (app *App) Login(email, password string) (string, string, uuid.UUID, error) {
 	user, err := app.db.GetUserByEmail(email)
 	if err != nil {
-		return "", uuid.Nil, err
+		return "", "", uuid.Nil, err
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return "", uuid.Nil, err
+		return "", "", uuid.Nil, err
+	}
+
+	session := &entities.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(REFRESH_TOKEN_TTL),
+	}
+
+	refreshToken, refreshTokenHash, err := newRefreshToken(session.ID)
+	if err != nil {
+		return "", "", uuid.Nil, err
 	}
 
-	token, err := newJWTToken(user.ID)
+	session.RefreshTokenHash = refreshTokenHash
+
+	err = app.db.CreateSession(session)
 	if err != nil {
-		return "", uuid.Nil, err
+		return "", "", uuid.Nil, err
 	}
 
-	return token, user.ID, nil
+	token, err := newJWTToken(user.ID, session.ID)
+	if err != nil {
+		return "", "", uuid.Nil, err
+	}
+
+	return token, refreshToken, user.ID, nil
+}
+
+func (app *App) RefreshToken(refreshToken string) (string, string, uuid.UUID, error) {
+	sessionId, refreshTokenHash, err := parseRefreshToken(refreshToken)
+	if err != nil {
+		return "", "", uuid.Nil, err
+	}
+
+	session, err := app.db.GetSession(sessionId)
+	if err != nil {
+		return "", "", uuid.Nil, errors.New("invalid refresh token")
+	}
+
+	if !isSessionActive(session.RevokedAt, session.ExpiresAt) {
+		return "", "", uuid.Nil, errors.New("session revoked")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(session.RefreshTokenHash), []byte(refreshTokenHash)) != 1 {
+		// An already rotated refresh token is being replayed, so the whole session is considered stolen.
+		_ = app.Logout(session.ID)
+		return "", "", uuid.Nil, errors.New("invalid refresh token")
+	}
+
+	newRefreshTokenString, newRefreshTokenHash, err := newRefreshToken(session.ID)
+	if err != nil {
+		return "", "", uuid.Nil, err
+	}
+
+	session.RefreshTokenHash = newRefreshTokenHash
+	session.ExpiresAt = time.Now().Add(REFRESH_TOKEN_TTL)
+
+	isRotated, err := app.db.RotateSession(session, refreshTokenHash)
+	if err != nil {
+		return "", "", uuid.Nil, err
+	}
+
+	if !isRotated {
+		// A concurrent refresh already rotated this token, so it is handled as a replay.
+		_ = app.Logout(session.ID)
+		return "", "", uuid.Nil, errors.New("invalid refresh token")
+	}
+
+	token, err := newJWTToken(session.UserID, session.ID)
+	if err != nil {
+		return "", "", uuid.Nil, err
+	}
+
+	return token, newRefreshTokenString, session.UserID, nil
+}
+
+func (app *App) Logout(sessionId uuid.UUID) error {
+	err := app.db.RevokeSession(sessionId)
+	if err != nil {
+		return err
+	}
+
+	app.messagingService.Publish(&msgsrvc.BroadcastMessage{
+		Type:     msgsrvc.SESSION_REVOKED,
+		Sessions: []uuid.UUID{sessionId},
+	})
+
+	return nil
+}
+
+func (app *App) LogoutAllDevices(userId uuid.UUID) error {
+	sessionIds, err := app.db.RevokeUserSessions(userId)
+	if err != nil {
+		return err
+	}
+
+	if len(*sessionIds) > 0 {
+		app.messagingService.Publish(&msgsrvc.BroadcastMessage{
+			Type:     msgsrvc.SESSION_REVOKED,
+			Sessions: *sessionIds,
+		})
+	}
+
+	return nil
 }
 
 func (app *App) Signup(user *entities.User) error {
@@ -33,6 +136,8 @@ func (app *App) Signup(user *entities.User) error {
 	}
 
 	user.Password = string(hashedPassword)
+	user.Status = entities.OFFLINE_STATUS
+	user.LastSeen = nil
 	return app.db.CreateUser(user)
 }
 
@@ -61,7 +166,14 @@ func (app *App) GetUserDMChannels(userId uuid.UUID, offset, limit int) (*[]entit
 }
 
 func (app *App) DeleteUser(id uuid.UUID) error {
-	return app.db.DeleteUser(id)
+	err := app.db.DeleteUser(id)
+	if err != nil {
+		return err
+	}
+
+	app.invalidateMemberships(id)
+
+	return nil
 }
 
 func (app *App) CreateServer(server *entities.Server, OwnerID uuid.UUID) error {
@@ -70,11 +182,11 @@ func (app *App) CreateServer(server *entities.Server, OwnerID uuid.UUID) error {
 		return err
 	}
 
-	err = app.db.AddServerMember(&entities.ServerMember{
+	_, err = app.db.AddServerMember(&entities.ServerMember{
 		ServerID: server.ID,
 		UserID:   OwnerID,
-		Role:     "owner",
-	})
+		Role:     OWNER_ROLE,
+	}, nil)
 
 	return err
 }
@@ -87,12 +199,33 @@ func (app *App) GetServerByName(name string) (*entities.Server, error) {
 	return app.db.GetServerByName(name)
 }
 
-func (app *App) GetAllServers(offset, limit int) (*[]entities.Server, error) {
-	return app.db.GetAllServers(offset, limit)
+func (app *App) GetAllServers(userId uuid.UUID, offset, limit int) (*[]entities.Server, error) {
+	return app.db.GetAllServers(userId, offset, limit)
 }
 
 func (app *App) UpdateServer(server *entities.Server) error {
-	return app.db.UpdateServer(server)
+	err := app.db.UpdateServer(server)
+	if err != nil {
+		return err
+	}
+
+	updatedServer, err := app.db.GetServer(server.ID)
+	if err != nil {
+		return err
+	}
+
+	app.publishDomainEvent(&msgsrvc.BroadcastMessage{
+		Event:    msgsrvc.SERVER_UPDATED,
+		ServerId: updatedServer.ID,
+	}, map[string]any{
+		"id":          updatedServer.ID,
+		"name":        updatedServer.Name,
+		"description": updatedServer.Description,
+		"photo":       updatedServer.Photo,
+		"created_at":  updatedServer.CreatedAt,
+	})
+
+	return nil
 }
 
 func (app *App) GetServerMembers(serverId uuid.UUID, offset, limit int) (*[]entities.User, error) {
@@ -100,15 +233,67 @@ func (app *App) GetServerMembers(serverId uuid.UUID, offset, limit int) (*[]enti
 }
 
 func (app *App) AddServerMember(serverId, userId uuid.UUID) error {
-	return app.db.AddServerMember(&entities.ServerMember{
+	channelIds, err := app.db.AddServerMember(&entities.ServerMember{
 		ServerID: serverId,
 		UserID:   userId,
-		Role:     "member",
-	})
+		Role:     MEMBER_ROLE,
+	}, nil)
+	if errors.Is(err, ports.ErrConflict) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	app.joinServer(serverId, userId, *channelIds, map[string]any{})
+
+	return nil
+}
+
+func (app *App) joinServer(serverId, userId uuid.UUID, channelIds []uuid.UUID, data map[string]any) {
+	app.invalidateMemberships(userId, append([]uuid.UUID{serverId}, channelIds...)...)
+	app.publishMemberEvent(msgsrvc.MEMBER_JOINED, serverId, userId, channelIds, data)
 }
 
 func (app *App) RemoveServerMember(serverId, userId uuid.UUID) error {
-	return app.db.RemoveServerMember(serverId, userId)
+	channelIds, err := app.db.GetServerChannelIds(serverId, "")
+	if err != nil {
+		return err
+	}
+
+	err = app.db.RemoveServerMember(serverId, userId)
+	if err != nil {
+		return err
+	}
+
+	app.invalidateMemberships(userId, append([]uuid.UUID{serverId}, *channelIds...)...)
+	app.publishMemberEvent(msgsrvc.MEMBER_REMOVED, serverId, userId, *channelIds, map[string]any{})
+
+	return nil
+}
+
+func (app *App) CreateInvite(invite *entities.ServerInvite) error {
+	code, err := newInviteCode()
+	if err != nil {
+		return err
+	}
+
+	invite.Code = code
+
+	return app.db.CreateInvite(invite)
+}
+
+func (app *App) GetInvite(code string) (*entities.ServerInvite, error) {
+	return app.db.GetInvite(code)
+}
+
+func (app *App) GetServerInvites(serverId uuid.UUID, offset, limit int) (*[]entities.ServerInvite, error) {
+	return app.db.GetServerInvites(serverId, offset, limit)
+}
+
+func (app *App) DeleteInvite(code string) error {
+	return app.db.DeleteInvite(code)
 }
 
 func (app *App) GetServerChannels(serverId, userId uuid.UUID, offset, limit int) (*[]entities.ServerChannel, error) {
@@ -116,7 +301,26 @@ func (app *App) GetServerChannels(serverId, userId uuid.UUID, offset, limit int)
 }
 
 func (app *App) DeleteServer(id uuid.UUID) error {
-	return app.db.DeleteServer(id)
+	channelIds, err := app.db.GetServerChannelIds(id, "")
+	if err != nil {
+		return err
+	}
+
+	err = app.db.DeleteServer(id)
+	if err != nil {
+		return err
+	}
+
+	app.invalidateMemberships(uuid.Nil, append([]uuid.UUID{id}, *channelIds...)...)
+	app.publishDomainEvent(&msgsrvc.BroadcastMessage{
+		Event:    msgsrvc.SERVER_DELETED,
+		ServerId: id,
+		Channels: *channelIds,
+	}, map[string]any{
+		"id": id,
+	})
+
+	return nil
 }
 
 func (app *App) CreateChannel(channel any, userId uuid.UUID, isServerChannel bool) error {
@@ -139,21 +343,53 @@ func (app *App) CreateChannel(channel any, userId uuid.UUID, isServerChannel boo
 		}
 	}
 
-	err = app.db.AddChannelMember(channelMember)
+	err = app.AddChannelMember(channelMember)
+	if err != nil {
+		return err
+	}
+
+	app.publishChannelEvent(msgsrvc.CHANNEL_CREATED, channel, userId)
 
-	return err
+	return nil
 }
 
 func (app *App) GetChannel(channel any) error {
 	return app.db.GetChannel(channel)
 }
 
-func (app *App) GetAllChannels(channels any, offset, limit int) error {
-	return app.db.GetAllChannels(channels, offset, limit)
+func (app *App) GetAllChannels(channels any, userId uuid.UUID, offset, limit int) error {
+	return app.db.GetAllChannels(channels, userId, offset, limit)
 }
 
 func (app *App) UpdateChannel(channel any) error {
-	return app.db.UpdateChannel(channel)
+	var previousMode string
+	if serverChannel, ok := channel.(*entities.ServerChannel); ok {
+		currentChannel := &entities.ServerChannel{Channel: entities.Channel{ID: serverChannel.ID}}
+		err := app.db.GetChannel(currentChannel)
+		if err != nil {
+			return err
+		}
+
+		previousMode = currentChannel.Mode
+	}
+
+	err := app.db.UpdateChannel(channel)
+	if err != nil {
+		return err
+	}
+
+	err = app.db.GetChannel(channel)
+	if err != nil {
+		return err
+	}
+
+	app.publishChannelEvent(msgsrvc.CHANNEL_UPDATED, channel, uuid.Nil)
+
+	if serverChannel, ok := channel.(*entities.ServerChannel); ok && serverChannel.Mode != previousMode {
+		app.publishAccessChange(serverChannel.ServerID, uuid.Nil, []uuid.UUID{serverChannel.ID})
+	}
+
+	return nil
 }
 
 func (app *App) GetChannelMembers(channelMembers any, channelId uuid.UUID, offset, limit int) error {
@@ -161,19 +397,98 @@ func (app *App) GetChannelMembers(channelMembers any, channelId uuid.UUID, offse
 }
 
 func (app *App) AddChannelMember(channelMember any) error {
-	return app.db.AddChannelMember(channelMember)
+	err := app.db.AddChannelMember(channelMember)
+	if err != nil {
+		return err
+	}
+
+	app.invalidateChannelMember(channelMember)
+
+	return nil
 }
 
 func (app *App) RemoveChannelMember(channelMember any) error {
-	return app.db.RemoveChannelMember(channelMember)
+	err := app.db.RemoveChannelMember(channelMember)
+	if err != nil {
+		return err
+	}
+
+	app.invalidateChannelMember(channelMember)
+
+	return nil
+}
+
+func (app *App) GetChannelMessages(channelMessages any, channelId uuid.UUID, cursor *entities.MessageCursor, limit int) error {
+	return app.db.GetChannelMessages(channelMessages, channelId, cursor, limit)
+}
+
+func (app *App) GetChannelOverrides(channelId uuid.UUID) (*[]entities.ChannelPermissionOverride, error) {
+	return app.db.GetChannelOverrides(channelId)
+}
+
+func (app *App) SetChannelOverride(override *entities.ChannelPermissionOverride) error {
+	channel := &entities.ServerChannel{Channel: entities.Channel{ID: override.ChannelID}}
+	err := app.db.GetChannel(channel)
+	if err != nil {
+		return err
+	}
+
+	if override.TargetType == entities.ROLE_OVERRIDE {
+		role, err := app.db.GetRole(override.TargetID)
+		if err != nil {
+			return err
+		}
+
+		if role.ServerID != channel.ServerID {
+			return errors.New("role does not belong to the channel's server")
+		}
+	}
+
+	err = app.db.SetChannelOverride(override)
+	if err != nil {
+		return err
+	}
+
+	app.publishAccessChange(channel.ServerID, uuid.Nil, []uuid.UUID{channel.ID})
+
+	return nil
 }
 
-func (app *App) GetChannelMessages(channelMessages any, channelId uuid.UUID, offset, limit int) error {
-	return app.db.GetChannelMessages(channelMessages, channelId, offset, limit)
+func (app *App) RemoveChannelOverride(channelId, targetId uuid.UUID) error {
+	channel := &entities.ServerChannel{Channel: entities.Channel{ID: channelId}}
+	err := app.db.GetChannel(channel)
+	if err != nil {
+		return err
+	}
+
+	err = app.db.RemoveChannelOverride(&entities.ChannelPermissionOverride{
+		ChannelID: channelId,
+		TargetID:  targetId,
+	})
+	if err != nil {
+		return err
+	}
+
+	app.publishAccessChange(channel.ServerID, uuid.Nil, []uuid.UUID{channel.ID})
+
+	return nil
 }
 
 func (app *App) DeleteChannel(channel any) error {
-	return app.db.DeleteChannel(channel)
+	err := app.db.GetChannel(channel)
+	if err != nil {
+		return err
+	}
+
+	err = app.db.DeleteChannel(channel)
+	if err != nil {
+		return err
+	}
+
+	app.invalidateMemberships(uuid.Nil, getChannelId(channel))
+	app.publishChannelEvent(msgsrvc.CHANNEL_DELETED, channel, uuid.Nil)
+
+	return nil
 }
 
 func (app *App) GetMessage(msg any) error {
@@ -181,38 +496,298 @@ func (app *App) GetMessage(msg any) error {
 }
 
 func (app *App) UpdateMessage(msg any) error {
-	return app.db.UpdateMessage(msg)
+	err := app.db.UpdateMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	err = app.db.GetMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	serverId, err := app.getMessageServerId(msg)
+	if err != nil {
+		return err
+	}
+
+	messageModel := entities.GetMessageModel(msg)
+	app.messagingService.Broadcast <- &msgsrvc.BroadcastMessage{
+		Type:      msgsrvc.MESSAGE_UPDATED,
+		ChannelId: messageModel.ChannelID,
+		ServerId:  serverId,
+		Message:   msg,
+	}
+
+	return nil
 }
 
 func (app *App) DeleteMessage(msg any) error {
-	return app.db.DeleteMessage(msg)
-}
+	err := app.db.DeleteMessage(msg)
+	if err != nil {
+		return err
+	}
 
-func (app *App) SendMessages(incomingMessage *msgsrvc.IncomingMessage) error {
-	var outgoingMessage any
-	if incomingMessage.ServerId != uuid.Nil {
-		outgoingMessage = &entities.ServerMessage{
-			Message: entities.Message{
-				ChannelID:  incomingMessage.ChannelId,
-				SenderID:   incomingMessage.SenderId,
-				Content:    incomingMessage.Content,
-				Attachment: incomingMessage.Attachment,
+	messageModel := entities.GetMessageModel(msg)
+	app.messagingService.Broadcast <- &msgsrvc.BroadcastMessage{
+		Type:      msgsrvc.CHANNEL_EVENT,
+		ChannelId: messageModel.ChannelID,
+		Message: map[string]any{
+			"type": msgsrvc.MESSAGE_DELETED,
+			"data": map[string]any{
+				"id":         messageModel.ID,
+				"channel_id": messageModel.ChannelID,
+				"parent_id":  messageModel.ParentID,
 			},
+		},
+	}
+
+	return nil
+}
+
+func (app *App) GetMessageRevisions(revisions any, messageId uuid.UUID, offset, limit int) error {
+	return app.db.GetMessageRevisions(revisions, messageId, offset, limit)
+}
+
+func (app *App) getMessageServerId(msg any) (uuid.UUID, error) {
+	serverMessage, ok := msg.(*entities.ServerMessage)
+	if !ok {
+		return uuid.Nil, nil
+	}
+
+	channel := &entities.ServerChannel{Channel: entities.Channel{ID: serverMessage.ChannelID}}
+	err := app.db.GetChannel(channel)
+
+	return channel.ServerID, err
+}
+
+func (app *App) GetMessageReplies(replies any, parentId uuid.UUID, offset, limit int) error {
+	return app.db.GetMessageReplies(replies, parentId, offset, limit)
+}
+
+func (app *App) SearchMessages(search *entities.MessageSearch) (*[]entities.MessageSearchResult, error) {
+	channelIds, err := app.getViewableServerChannelIds(search.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	search.ChannelIDs = *channelIds
+
+	return app.db.SearchMessages(search)
+}
+
+func (app *App) AddReaction(messageId, userId uuid.UUID, emoji string, isServerMessage bool) error {
+	return app.updateReaction(messageId, userId, emoji, isServerMessage, true)
+}
+
+func (app *App) RemoveReaction(messageId, userId uuid.UUID, emoji string, isServerMessage bool) error {
+	return app.updateReaction(messageId, userId, emoji, isServerMessage, false)
+}
+
+func (app *App) GetReactionCounts(messageIds []uuid.UUID, isServerMessage bool) (*[]entities.ReactionCount, error) {
+	return app.db.GetReactionCounts(newReaction(uuid.Nil, uuid.Nil, "", isServerMessage), messageIds)
+}
+
+func (app *App) updateReaction(messageId, userId uuid.UUID, emoji string, isServerMessage, isAdded bool) error {
+	var message any
+	if isServerMessage {
+		message = &entities.ServerMessage{Message: entities.Message{ID: messageId}}
+	} else {
+		message = &entities.DirectMessage{Message: entities.Message{ID: messageId}}
+	}
+
+	err := app.db.GetMessage(message)
+	if err != nil {
+		return err
+	}
+
+	eventType := msgsrvc.REACTION_ADDED
+	if isAdded {
+		err = app.db.AddReaction(newReaction(messageId, userId, emoji, isServerMessage))
+	} else {
+		eventType = msgsrvc.REACTION_REMOVED
+		err = app.db.RemoveReaction(newReaction(messageId, userId, emoji, isServerMessage))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	reactionCounts, err := app.GetReactionCounts([]uuid.UUID{messageId}, isServerMessage)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, reactionCount := range *reactionCounts {
+		if reactionCount.Emoji == emoji {
+			count = reactionCount.Count
 		}
+	}
+
+	channelId := entities.GetMessageModel(message).ChannelID
+	app.messagingService.Broadcast <- &msgsrvc.BroadcastMessage{
+		Type:      msgsrvc.CHANNEL_EVENT,
+		ChannelId: channelId,
+		Message: map[string]any{
+			"type": eventType,
+			"data": map[string]any{
+				"message_id": messageId,
+				"channel_id": channelId,
+				"user_id":    userId,
+				"emoji":      emoji,
+				"count":      count,
+			},
+		},
+	}
+
+	return nil
+}
+
+func newReaction(messageId, userId uuid.UUID, emoji string, isServerMessage bool) any {
+	reaction := entities.Reaction{
+		MessageID: messageId,
+		UserID:    userId,
+		Emoji:     emoji,
+	}
+
+	if isServerMessage {
+		return &entities.ServerMessageReaction{Reaction: reaction}
+	}
+
+	return &entities.DirectMessageReaction{Reaction: reaction}
+}
+
+func (app *App) MarkRead(markRead *msgsrvc.MarkRead, isServerChannel bool) error {
+	var message any
+	if isServerChannel {
+		message = &entities.ServerMessage{Message: entities.Message{ID: markRead.MessageId}}
 	} else {
-		outgoingMessage = &entities.DirectMessage{
-			Message: entities.Message{
-				ChannelID:  incomingMessage.ChannelId,
-				SenderID:   incomingMessage.SenderId,
-				Content:    incomingMessage.Content,
-				Attachment: incomingMessage.Attachment,
+		message = &entities.DirectMessage{Message: entities.Message{ID: markRead.MessageId}}
+	}
+
+	err := app.db.GetMessage(message)
+	if err != nil {
+		return err
+	}
+
+	messageModel := entities.GetMessageModel(message)
+	if messageModel.ChannelID != markRead.ChannelId {
+		return fmt.Errorf("%w: message does not belong to the channel", ErrValidation)
+	}
+
+	readState := &entities.ChannelReadState{
+		ChannelID:         markRead.ChannelId,
+		UserID:            markRead.SenderId,
+		LastReadMessageID: &messageModel.ID,
+		LastReadAt:        messageModel.SentAt,
+	}
+
+	advanced, err := app.db.SetReadState(readState)
+	if err != nil || !advanced {
+		return err
+	}
+
+	app.messagingService.Broadcast <- &msgsrvc.BroadcastMessage{
+		Type:   msgsrvc.USER_EVENT,
+		UserId: markRead.SenderId,
+		Message: map[string]any{
+			"type": msgsrvc.READ_STATE_UPDATED,
+			"data": map[string]any{
+				"channel_id":   readState.ChannelID,
+				"message_id":   readState.LastReadMessageID,
+				"last_read_at": readState.LastReadAt,
 			},
+		},
+	}
+
+	return nil
+}
+
+func (app *App) GetUnreadCounts(userId uuid.UUID, channelIds []uuid.UUID, isServerChannel bool) (*[]entities.UnreadCount, error) {
+	return app.db.GetUnreadCounts(userId, channelIds, isServerChannel)
+}
+
+func (app *App) GetServerUnreadCounts(userId uuid.UUID, serverIds []uuid.UUID) (*[]entities.UnreadCount, error) {
+	return app.db.GetServerUnreadCounts(userId, serverIds)
+}
+
+func (app *App) SendMessages(incomingMessage *msgsrvc.IncomingMessage) (any, error) {
+	isServerMessage := incomingMessage.ServerId != uuid.Nil
+
+	err := app.AuthorizeMessagingChannel(incomingMessage.SenderId, incomingMessage.ServerId, incomingMessage.ChannelId,
+		SEND_MESSAGE)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(incomingMessage.Nonce) > MAX_NONCE_LENGTH {
+		return nil, fmt.Errorf("%w: nonce is too long", ErrValidation)
+	}
+
+	message := entities.Message{
+		ChannelID:  incomingMessage.ChannelId,
+		SenderID:   incomingMessage.SenderId,
+		Content:    incomingMessage.Content,
+		Attachment: incomingMessage.Attachment,
+	}
+
+	if incomingMessage.ParentId != uuid.Nil {
+		threadId, err := app.getThreadId(incomingMessage.ParentId, incomingMessage.ChannelId, isServerMessage)
+		if err != nil {
+			return nil, err
 		}
+
+		message.ParentID = &threadId
 	}
 
-	err := app.db.CreateMessage(outgoingMessage)
+	if incomingMessage.Nonce != "" {
+		message.Nonce = &incomingMessage.Nonce
+	}
+
+	var outgoingMessage any
+	if isServerMessage {
+		outgoingMessage = &entities.ServerMessage{Message: message}
+	} else {
+		outgoingMessage = &entities.DirectMessage{Message: message}
+	}
+
+	nonceSince := time.Now().Add(-NONCE_DEDUP_WINDOW)
+	if message.Nonce != nil {
+		duplicate, isDuplicate, err := app.findMessageByNonce(isServerMessage, message.SenderID, *message.Nonce, nonceSince)
+		if err != nil {
+			return nil, err
+		}
+
+		if isDuplicate {
+			return duplicate, nil
+		}
+	}
+
+	mentions, err := app.getMessageMentions(incomingMessage.SenderId, incomingMessage.ServerId,
+		incomingMessage.ChannelId, incomingMessage.Content)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	entities.SetMessageMentions(outgoingMessage, mentions)
+
+	err = app.db.CreateMessage(outgoingMessage, nonceSince)
+	if errors.Is(err, ports.ErrConflict) && message.Nonce != nil {
+		// a retry with the same nonce was stored between the lookup above and
+		// the insert, answer with the message it stored instead of failing
+		duplicate, isDuplicate, findErr := app.findMessageByNonce(isServerMessage, message.SenderID, *message.Nonce, nonceSince)
+		if findErr != nil {
+			return nil, findErr
+		}
+
+		if isDuplicate {
+			return duplicate, nil
+		}
+	}
+
+	if err != nil {
+		return nil, err
 	}
 
 	app.messagingService.Broadcast <- &msgsrvc.BroadcastMessage{
@@ -222,9 +797,67 @@ func (app *App) SendMessages(incomingMessage *msgsrvc.IncomingMessage) error {
 		Message:   outgoingMessage,
 	}
 
+	app.notifyMentions(outgoingMessage, incomingMessage.ServerId)
+
+	return outgoingMessage, nil
+}
+
+func (app *App) findMessageByNonce(isServerMessage bool, senderId uuid.UUID, nonce string, since time.Time) (any, bool, error) {
+	var message any = &entities.DirectMessage{}
+	if isServerMessage {
+		message = &entities.ServerMessage{}
+	}
+
+	isFound, err := app.db.FindMessageByNonce(message, senderId, nonce, since)
+
+	return message, isFound, err
+}
+
+func (app *App) ReceiveMessages(client *msgsrvc.Client) <-chan any {
+	return client.MessagingChannel
+}
+
+func (app *App) SendTyping(typing *msgsrvc.Typing) error {
+	err := app.AuthorizeMessagingChannel(typing.SenderId, typing.ServerId, typing.ChannelId, SEND_MESSAGE)
+	if err != nil {
+		return err
+	}
+
+	app.messagingService.Broadcast <- &msgsrvc.BroadcastMessage{
+		Type:      typing.Type,
+		ServerId:  typing.ServerId,
+		ChannelId: typing.ChannelId,
+		SenderId:  typing.SenderId,
+	}
+
 	return nil
 }
 
+func (app *App) getThreadId(parentId, channelId uuid.UUID, isServerMessage bool) (uuid.UUID, error) {
+	var parent any
+	if isServerMessage {
+		parent = &entities.ServerMessage{Message: entities.Message{ID: parentId}}
+	} else {
+		parent = &entities.DirectMessage{Message: entities.Message{ID: parentId}}
+	}
+
+	err := app.db.GetMessage(parent)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	parentModel := entities.GetMessageModel(parent)
+	if parentModel.ChannelID != channelId {
+		return uuid.Nil, fmt.Errorf("%w: parent message belongs to another channel", ErrValidation)
+	}
+
+	if parentModel.ParentID != nil {
+		return *parentModel.ParentID, nil
+	}
+
+	return parentModel.ID, nil
+}
+
 func (app *App) SendNotification(notificationObj any, serverId uuid.UUID) error {
 	app.messagingService.Broadcast <- &msgsrvc.BroadcastMessage{
 		Type:     msgsrvc.NOTIFICATION,
@@ -235,13 +868,8 @@ func (app *App) SendNotification(notificationObj any, serverId uuid.UUID) error
 	return nil
 }
 
-func (app *App) ReceiveMessages(client *msgsrvc.Client) (any, bool) {
-	msg, ok := <-client.MessagingChannel
-	return msg, ok
-}
-
-func (app *App) ConnectWebsocket(clientId uuid.UUID) (*msgsrvc.Client, error) {
-	channelIds, err := app.db.GetUserChannelIds(clientId)
+func (app *App) ConnectWebsocket(clientId, sessionId uuid.UUID, lastSeq uint64) (*msgsrvc.Client, error) {
+	channelIds, err := app.getViewableChannelIds(clientId)
 	if err != nil {
 		return nil, err
 	}
@@ -253,26 +881,112 @@ func (app *App) ConnectWebsocket(clientId uuid.UUID) (*msgsrvc.Client, error) {
 
 	client := &msgsrvc.Client{
 		ID:               clientId,
-		MessagingChannel: make(chan any, 10),
+		ConnectionID:     uuid.New(),
+		SessionID:        sessionId,
+		MessagingChannel: make(chan any, app.messagingService.QueueSize),
 	}
 
 	app.messagingService.Connect <- &msgsrvc.NewClient{
 		ClientObj: client,
 		Servers:   serverIds,
 		Channels:  channelIds,
+		IsResumed: lastSeq != 0,
+		LastSeq:   lastSeq,
 	}
 
 	return client, nil
 }
 
+func (app *App) getViewableChannelIds(userId uuid.UUID) (*[]uuid.UUID, error) {
+	channelIds, err := app.db.GetUserChannelIds(userId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	serverChannelIds, err := app.getViewableServerChannelIds(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	*channelIds = append(*channelIds, *serverChannelIds...)
+
+	return channelIds, nil
+}
+
+func (app *App) getViewableServerChannelIds(userId uuid.UUID) (*[]uuid.UUID, error) {
+	channelIds, err := app.db.GetUserChannelIds(userId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	viewableChannelIds := make([]uuid.UUID, 0, len(*channelIds))
+	for _, channelId := range *channelIds {
+		if app.AuthorizeChannel(userId, channelId, true, VIEW_CHANNEL) == nil {
+			viewableChannelIds = append(viewableChannelIds, channelId)
+		}
+	}
+
+	return &viewableChannelIds, nil
+}
+
 func (app *App) DisconnectWebsocket(client *msgsrvc.Client) {
 	app.messagingService.Disconnect <- client
 
-	close(client.MessagingChannel)
+	client.Close()
+}
+
+func (app *App) ForwardStreamFrame(userId, connectionId uuid.UUID, payload []byte) {
+	app.messagingService.Publish(&msgsrvc.BroadcastMessage{
+		Type:         msgsrvc.STREAM_FRAME,
+		SenderId:     userId,
+		ConnectionId: connectionId,
+		Message:      string(payload),
+	})
+}
+
+func (app *App) ReceiveStreamFrames() <-chan *msgsrvc.BroadcastMessage {
+	return app.messagingService.StreamFrames
+}
+
+func (app *App) RecordActivity(client *msgsrvc.Client) {
+	app.messagingService.Activity <- client
+}
+
+func (app *App) TrackMemberships() {
+	for change := range app.messagingService.MembershipChanges {
+		switch change := change.(type) {
+		case *msgsrvc.BroadcastMessage:
+			app.memberships.invalidateMemberships(change.UserId, change.Scopes)
+		case *msgsrvc.AccessCheck:
+			app.checkChannelAccess(change)
+		}
+	}
+}
+
+func (app *App) GetMessagingStats() *msgsrvc.Stats {
+	return app.messagingService.Stats()
 }
 
-func (app *App) JoinChannels(clientObj *msgsrvc.Client, serverId uuid.UUID, channels []uuid.UUID) {
+func (app *App) JoinChannels(clientObj *msgsrvc.Client, serverId uuid.UUID, channels []uuid.UUID) error {
+	err := app.AuthorizeServer(clientObj.ID, serverId, VIEW_SERVER)
+	if err != nil {
+		return err
+	}
+
+	rejectedChannels := []uuid.UUID{}
+	for _, channelId := range channels {
+		if app.AuthorizeMessagingChannel(clientObj.ID, serverId, channelId, VIEW_CHANNEL) != nil {
+			rejectedChannels = append(rejectedChannels, channelId)
+		}
+	}
+
+	if len(rejectedChannels) > 0 {
+		return fmt.Errorf("%w: cannot view channels %v", ErrForbidden, rejectedChannels)
+	}
+
 	app.messagingService.JoinChannels(clientObj, serverId, channels)
+
+	return nil
 }
 
 func (app *App) QuitChannel(clientObj *msgsrvc.Client, channelId uuid.UUID) {
@@ -280,23 +994,90 @@ func (app *App) QuitChannel(clientObj *msgsrvc.Client, channelId uuid.UUID) {
 }
 
 func (app *App) QuitServer(clientObj *msgsrvc.Client, serverId uuid.UUID) {
-	channels, _ := app.GetServerChannels(serverId, clientObj.ID, 0, 1000)
+	channelIds, err := app.db.GetServerChannelIds(serverId, "")
+	if err != nil {
+		log.Println(err)
+		channelIds = &[]uuid.UUID{}
+	}
+
+	app.messagingService.QuitServer(clientObj, serverId, *channelIds)
+}
+
+func (app *App) CreateRole(role *entities.ServerRole) error {
+	return app.db.CreateRole(role)
+}
+
+func (app *App) GetRole(id uuid.UUID) (*entities.ServerRole, error) {
+	return app.db.GetRole(id)
+}
 
-	for _, channel := range *channels {
-		app.QuitChannel(clientObj, channel.ID)
+func (app *App) GetServerRoles(serverId uuid.UUID, offset, limit int) (*[]entities.ServerRole, error) {
+	return app.db.GetServerRoles(serverId, offset, limit)
+}
+
+func (app *App) UpdateRole(role *entities.ServerRole) error {
+	err := app.db.UpdateRole(role)
+	if err != nil {
+		return err
 	}
 
-	app.messagingService.QuitServer(clientObj, serverId)
+	app.publishServerAccessChange(role.ServerID, uuid.Nil)
+
+	return nil
 }
 
-func (app *App) RemoveChannel(channelId uuid.UUID) {
-	app.messagingService.RemoveChannel(channelId)
+func (app *App) DeleteRole(id uuid.UUID) error {
+	role, err := app.db.GetRole(id)
+	if err != nil {
+		return err
+	}
+
+	err = app.db.DeleteRole(id)
+	if err != nil {
+		return err
+	}
+
+	app.publishServerAccessChange(role.ServerID, uuid.Nil)
+
+	return nil
 }
 
-func (app *App) RemoveServer(serverId uuid.UUID) {
-	app.messagingService.RemoveServer(serverId)
+func (app *App) AddMemberRole(serverId, userId, roleId uuid.UUID) error {
+	_, err := app.getServerMemberRole(serverId, userId)
+	if err != nil {
+		return err
+	}
+
+	err = app.db.AddMemberRole(&entities.ServerMemberRole{
+		ServerID: serverId,
+		UserID:   userId,
+		RoleID:   roleId,
+	})
+	if err != nil {
+		return err
+	}
+
+	app.publishServerAccessChange(serverId, userId)
+
+	return nil
 }
 
-func (app *App) GetServerMemberRole(serverId, userId uuid.UUID) (string, error) {
-	return app.db.GetServerMemberRole(serverId, userId)
+func (app *App) RemoveMemberRole(serverId, userId, roleId uuid.UUID) error {
+	_, err := app.getServerMemberRole(serverId, userId)
+	if err != nil {
+		return err
+	}
+
+	err = app.db.RemoveMemberRole(&entities.ServerMemberRole{
+		ServerID: serverId,
+		UserID:   userId,
+		RoleID:   roleId,
+	})
+	if err != nil {
+		return err
+	}
+
+	app.publishServerAccessChange(serverId, userId)
+
+	return nil
 }
@@ -7,7 +7,10 @@ import (
 )
 
 type AppI interface {
-	Login(email, password string) (string, uuid.UUID, error)
+	Login(email, password string) (string, string, uuid.UUID, error)
+	RefreshToken(refreshToken string) (string, string, uuid.UUID, error)
+	Logout(sessionId uuid.UUID) error
+	LogoutAllDevices(userId uuid.UUID) error
 	Signup(user *entities.User) error
 	GetUser(id uuid.UUID) (*entities.User, error)
 	GetUserByEmail(email string) (*entities.User, error)
@@ -20,41 +23,81 @@ type AppI interface {
 	CreateServer(server *entities.Server, OwnerID uuid.UUID) error
 	GetServer(id uuid.UUID) (*entities.Server, error)
 	GetServerByName(name string) (*entities.Server, error)
-	GetAllServers(offset, limit int) (*[]entities.Server, error)
+	GetAllServers(userId uuid.UUID, offset, limit int) (*[]entities.Server, error)
 	UpdateServer(server *entities.Server) error
 	GetServerMembers(serverId uuid.UUID, offset, limit int) (*[]entities.User, error)
 	AddServerMember(serverId, userId uuid.UUID) error
 	RemoveServerMember(serverId, userId uuid.UUID) error
+	CreateInvite(invite *entities.ServerInvite) error
+	GetInvite(code string) (*entities.ServerInvite, error)
+	GetServerInvites(serverId uuid.UUID, offset, limit int) (*[]entities.ServerInvite, error)
+	DeleteInvite(code string) error
+	RedeemInvite(code string, userId uuid.UUID) (*entities.Server, error)
 	GetServerChannels(serverId, userId uuid.UUID, offset, limit int) (*[]entities.ServerChannel, error)
 	DeleteServer(id uuid.UUID) error
 
 	CreateChannel(channel any, userId uuid.UUID, isServerChannel bool) error
 	GetChannel(channel any) error
-	GetAllChannels(channels any, offset, limit int) error
+	GetAllChannels(channels any, userId uuid.UUID, offset, limit int) error
 	UpdateChannel(channel any) error
 	GetChannelMembers(channelMembers any, channelId uuid.UUID, offset, limit int) error
 	AddChannelMember(channelMember any) error
 	RemoveChannelMember(channelMember any) error
-	GetChannelMessages(channelMessages any, channelId uuid.UUID, offset, limit int) error
+	GetChannelMessages(channelMessages any, channelId uuid.UUID, cursor *entities.MessageCursor, limit int) error
+	GetChannelOverrides(channelId uuid.UUID) (*[]entities.ChannelPermissionOverride, error)
+	SetChannelOverride(override *entities.ChannelPermissionOverride) error
+	RemoveChannelOverride(channelId, targetId uuid.UUID) error
 	DeleteChannel(channel any) error
 
 	GetMessage(msg any) error
 	UpdateMessage(msg any) error
 	DeleteMessage(msg any) error
+	GetMessageReplies(replies any, parentId uuid.UUID, offset, limit int) error
+	SearchMessages(search *entities.MessageSearch) (*[]entities.MessageSearchResult, error)
+	GetMessageRevisions(revisions any, messageId uuid.UUID, offset, limit int) error
+	AddReaction(messageId, userId uuid.UUID, emoji string, isServerMessage bool) error
+	RemoveReaction(messageId, userId uuid.UUID, emoji string, isServerMessage bool) error
+	GetReactionCounts(messageIds []uuid.UUID, isServerMessage bool) (*[]entities.ReactionCount, error)
+	MarkRead(markRead *msgsrvc.MarkRead, isServerChannel bool) error
+	GetUnreadCounts(userId uuid.UUID, channelIds []uuid.UUID, isServerChannel bool) (*[]entities.UnreadCount, error)
+	GetServerUnreadCounts(userId uuid.UUID, serverIds []uuid.UUID) (*[]entities.UnreadCount, error)
 
-	ValidateJWTToken(tokenString string) (uuid.UUID, error)
+	ValidateJWTToken(tokenString string) (uuid.UUID, uuid.UUID, error)
 
-	SendMessages(incomingMessage *msgsrvc.IncomingMessage) error
+	AuthorizeUser(actorId, userId uuid.UUID) error
+	AuthorizeOperator(actorId uuid.UUID) error
+	AuthorizeServer(actorId, serverId uuid.UUID, action string) error
+	AuthorizeServerMemberRemoval(actorId, serverId, userId uuid.UUID) error
+	AuthorizeRole(actorId uuid.UUID, role *entities.ServerRole) error
+	AuthorizeChannel(actorId, channelId uuid.UUID, isServerChannel bool, action string) error
+	AuthorizeChannelMember(actorId, channelId, userId uuid.UUID, isServerChannel bool, action string) error
+	AuthorizeMessage(actorId, messageId uuid.UUID, isServerMessage bool, action string) error
+	AuthorizeMessagingChannel(actorId, serverId, channelId uuid.UUID, action string) error
+
+	SendMessages(incomingMessage *msgsrvc.IncomingMessage) (any, error)
+	SendTyping(typing *msgsrvc.Typing) error
+	ReceiveMessages(client *msgsrvc.Client) <-chan any
+	RecordActivity(client *msgsrvc.Client)
+	ForwardStreamFrame(userId, connectionId uuid.UUID, payload []byte)
+	ReceiveStreamFrames() <-chan *msgsrvc.BroadcastMessage
+	TrackPresence()
+	TrackMemberships()
+	GetMessagingStats() *msgsrvc.Stats
 	SendNotification(notificationObj any, serverId uuid.UUID) error
-	ReceiveMessages(client *msgsrvc.Client) (any, bool)
 
-	ConnectWebsocket(clientId uuid.UUID) (*msgsrvc.Client, error)
-	JoinChannels(clientObj *msgsrvc.Client, serverId uuid.UUID, channels []uuid.UUID)
+	ConnectWebsocket(clientId, sessionId uuid.UUID, lastSeq uint64) (*msgsrvc.Client, error)
+	JoinChannels(clientObj *msgsrvc.Client, serverId uuid.UUID, channels []uuid.UUID) error
 	QuitChannel(clientObj *msgsrvc.Client, channelId uuid.UUID)
 	QuitServer(clientObj *msgsrvc.Client, serverId uuid.UUID)
-	RemoveChannel(channelId uuid.UUID)
-	RemoveServer(serverId uuid.UUID)
 	DisconnectWebsocket(client *msgsrvc.Client)
 
-	GetServerMemberRole(serverId, userId uuid.UUID) (string, error)
+	GetServerMemberPermissions(serverId, userId uuid.UUID) (string, entities.Permissions, error)
+
+	CreateRole(role *entities.ServerRole) error
+	GetRole(id uuid.UUID) (*entities.ServerRole, error)
+	GetServerRoles(serverId uuid.UUID, offset, limit int) (*[]entities.ServerRole, error)
+	UpdateRole(role *entities.ServerRole) error
+	DeleteRole(id uuid.UUID) error
+	AddMemberRole(serverId, userId, roleId uuid.UUID) error
+	RemoveMemberRole(serverId, userId, roleId uuid.UUID) error
 }
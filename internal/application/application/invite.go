@@ -0,0 +1,58 @@
+package application
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/ports"
+	"github.com/google/uuid"
+)
+
+const INVITE_CODE_LENGTH = 10
+
+const inviteCodeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+var ErrInvalidInvite = errors.New("invite is invalid or expired")
+
+func newInviteCode() (string, error) {
+	code := make([]byte, INVITE_CODE_LENGTH)
+	alphabetSize := big.NewInt(int64(len(inviteCodeAlphabet)))
+
+	for idx := range code {
+		charIdx, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+
+		code[idx] = inviteCodeAlphabet[charIdx.Int64()]
+	}
+
+	return string(code), nil
+}
+
+func (app *App) RedeemInvite(code string, userId uuid.UUID) (*entities.Server, error) {
+	invite, channelIds, err := app.db.RedeemInvite(code, &entities.ServerMember{
+		UserID: userId,
+		Role:   MEMBER_ROLE,
+	})
+
+	if errors.Is(err, ports.ErrNotFound) {
+		return nil, ErrInvalidInvite
+	}
+
+	if errors.Is(err, ports.ErrConflict) {
+		return app.db.GetServer(invite.ServerID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	app.joinServer(invite.ServerID, userId, *channelIds, map[string]any{
+		"invite": invite.Code,
+	})
+
+	return app.db.GetServer(invite.ServerID)
+}
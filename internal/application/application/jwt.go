@@ -1,18 +1,29 @@
 package application
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"os"
+	"strings"
 	"time"
 )
 
-func newJWTToken(userId uuid.UUID) (string, error) {
+const (
+	ACCESS_TOKEN_TTL  = 15 * time.Minute
+	REFRESH_TOKEN_TTL = 30 * 24 * time.Hour
+)
+
+func newJWTToken(userId, sessionId uuid.UUID) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId":  userId,
-		"expires": time.Now().Add(7 * 24 * time.Hour).Unix(),
+		"userId":    userId,
+		"sessionId": sessionId,
+		"expires":   time.Now().Add(ACCESS_TOKEN_TTL).Unix(),
 	})
 
 	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_KEY")))
@@ -23,7 +34,38 @@ func newJWTToken(userId uuid.UUID) (string, error) {
 	return tokenString, nil
 }
 
-func (app *App) ValidateJWTToken(tokenString string) (uuid.UUID, error) {
+func newRefreshToken(sessionId uuid.UUID) (string, string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+
+	return sessionId.String() + "." + encodedSecret, hashRefreshToken(encodedSecret), nil
+}
+
+func parseRefreshToken(refreshToken string) (uuid.UUID, string, error) {
+	sessionId, secret, found := strings.Cut(refreshToken, ".")
+	if !found || secret == "" {
+		return uuid.Nil, "", errors.New("invalid refresh token")
+	}
+
+	uSessionId, err := uuid.Parse(sessionId)
+	if err != nil {
+		return uuid.Nil, "", errors.New("invalid refresh token")
+	}
+
+	return uSessionId, hashRefreshToken(secret), nil
+}
+
+func hashRefreshToken(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func (app *App) ValidateJWTToken(tokenString string) (uuid.UUID, uuid.UUID, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -33,25 +75,43 @@ func (app *App) ValidateJWTToken(tokenString string) (uuid.UUID, error) {
 	})
 
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, uuid.Nil, err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return uuid.Nil, errors.New("invalid JWT token")
+		return uuid.Nil, uuid.Nil, errors.New("invalid JWT token")
 	}
 
-	expires := claims["expires"]
-	if float64(time.Now().Unix()) > expires.(float64) {
-		return uuid.Nil, errors.New("token expired")
+	expires, ok := claims["expires"].(float64)
+	if !ok || float64(time.Now().Unix()) > expires {
+		return uuid.Nil, uuid.Nil, errors.New("token expired")
 	}
 
-	userId := claims["userId"]
+	userId, _ := claims["userId"].(string)
+	uUserId, err := uuid.Parse(userId)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
 
-	uUserId, err := uuid.Parse(userId.(string))
+	sessionId, _ := claims["sessionId"].(string)
+	uSessionId, err := uuid.Parse(sessionId)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, uuid.Nil, err
 	}
 
-	return uUserId, nil
+	session, err := app.db.GetSession(uSessionId)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("session not found")
+	}
+
+	if !isSessionActive(session.RevokedAt, session.ExpiresAt) || session.UserID != uUserId {
+		return uuid.Nil, uuid.Nil, errors.New("session revoked")
+	}
+
+	return uUserId, uSessionId, nil
+}
+
+func isSessionActive(revokedAt *time.Time, expiresAt time.Time) bool {
+	return revokedAt == nil && time.Now().Before(expiresAt)
 }
@@ -0,0 +1,244 @@
+package application
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/application/core/msgsrvc"
+	"github.com/critch-app/critch-backend/internal/ports"
+	"github.com/google/uuid"
+)
+
+const (
+	MEMBERSHIP_CACHE_TTL         = 30 * time.Second
+	MEMBERSHIP_CACHE_MAX_ENTRIES = 100000
+)
+
+type membershipKey struct {
+	scopeId uuid.UUID
+	userId  uuid.UUID
+}
+
+type membership struct {
+	isMember  bool
+	role      string
+	expiresAt time.Time
+}
+
+type membershipCache struct {
+	mutex   sync.RWMutex
+	entries map[membershipKey]membership
+	sweptAt time.Time
+}
+
+func newMembershipCache() *membershipCache {
+	return &membershipCache{
+		entries: make(map[membershipKey]membership),
+	}
+}
+
+func (cache *membershipCache) get(scopeId, userId uuid.UUID) (membership, bool) {
+	key := membershipKey{scopeId, userId}
+
+	cache.mutex.RLock()
+	entry, exists := cache.entries[key]
+	cache.mutex.RUnlock()
+
+	if !exists {
+		return membership{}, false
+	}
+
+	if time.Now().After(entry.expiresAt) {
+		cache.mutex.Lock()
+		defer cache.mutex.Unlock()
+
+		if entry, exists := cache.entries[key]; exists && time.Now().After(entry.expiresAt) {
+			delete(cache.entries, key)
+		}
+
+		return membership{}, false
+	}
+
+	return entry, true
+}
+
+func (cache *membershipCache) set(scopeId, userId uuid.UUID, entry membership) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(cache.sweptAt) > MEMBERSHIP_CACHE_TTL {
+		cache.sweep(now)
+	}
+
+	key := membershipKey{scopeId, userId}
+	if _, exists := cache.entries[key]; !exists && len(cache.entries) >= MEMBERSHIP_CACHE_MAX_ENTRIES {
+		return
+	}
+
+	entry.expiresAt = now.Add(MEMBERSHIP_CACHE_TTL)
+	cache.entries[key] = entry
+}
+
+func (cache *membershipCache) sweep(now time.Time) {
+	for key, entry := range cache.entries {
+		if now.After(entry.expiresAt) {
+			delete(cache.entries, key)
+		}
+	}
+
+	cache.sweptAt = now
+}
+
+func (cache *membershipCache) invalidate(scopeId, userId uuid.UUID) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	delete(cache.entries, membershipKey{scopeId, userId})
+}
+
+func (cache *membershipCache) invalidateScope(scopeId uuid.UUID) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for key := range cache.entries {
+		if key.scopeId == scopeId {
+			delete(cache.entries, key)
+		}
+	}
+}
+
+func (cache *membershipCache) invalidateUser(userId uuid.UUID) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for key := range cache.entries {
+		if key.userId == userId {
+			delete(cache.entries, key)
+		}
+	}
+}
+
+func (cache *membershipCache) invalidateMemberships(userId uuid.UUID, scopes []uuid.UUID) {
+	if len(scopes) == 0 {
+		cache.invalidateUser(userId)
+		return
+	}
+
+	for _, scopeId := range scopes {
+		if userId == uuid.Nil {
+			cache.invalidateScope(scopeId)
+		} else {
+			cache.invalidate(scopeId, userId)
+		}
+	}
+}
+
+func (app *App) getServerMemberRole(serverId, userId uuid.UUID) (string, error) {
+	if entry, exists := app.memberships.get(serverId, userId); exists {
+		if !entry.isMember {
+			return "", ports.ErrNotFound
+		}
+
+		return entry.role, nil
+	}
+
+	role, err := app.db.GetServerMemberRole(serverId, userId)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			app.memberships.set(serverId, userId, membership{})
+		}
+
+		return "", err
+	}
+
+	app.memberships.set(serverId, userId, membership{isMember: true, role: role})
+
+	return role, nil
+}
+
+func (app *App) isChannelMember(channelMember any) bool {
+	channelId, userId := getChannelMemberKey(channelMember)
+	if entry, exists := app.memberships.get(channelId, userId); exists {
+		return entry.isMember
+	}
+
+	err := app.db.GetChannelMember(channelMember)
+	if err != nil && !errors.Is(err, ports.ErrNotFound) {
+		return false
+	}
+
+	app.memberships.set(channelId, userId, membership{isMember: err == nil})
+
+	return err == nil
+}
+
+func (app *App) invalidateChannelMember(channelMember any) {
+	channelId, userId := getChannelMemberKey(channelMember)
+	app.invalidateMemberships(userId, channelId)
+}
+
+func (app *App) invalidateMemberships(userId uuid.UUID, scopes ...uuid.UUID) {
+	app.memberships.invalidateMemberships(userId, scopes)
+	app.messagingService.Broadcast <- &msgsrvc.BroadcastMessage{
+		Type:   msgsrvc.MEMBERSHIP_CHANGED,
+		UserId: userId,
+		Scopes: scopes,
+	}
+}
+
+func (app *App) publishAccessChange(serverId, userId uuid.UUID, channels []uuid.UUID) {
+	app.messagingService.Publish(&msgsrvc.BroadcastMessage{
+		Type:     msgsrvc.ACCESS_CHANGED,
+		ServerId: serverId,
+		UserId:   userId,
+		Channels: channels,
+	})
+}
+
+func (app *App) publishServerAccessChange(serverId, userId uuid.UUID) {
+	channelIds, err := app.db.GetServerChannelIds(serverId, "")
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	app.publishAccessChange(serverId, userId, *channelIds)
+}
+
+func (app *App) checkChannelAccess(check *msgsrvc.AccessCheck) {
+	for _, client := range check.Clients {
+		err := app.AuthorizeMessagingChannel(client.ID, check.ServerId, check.ChannelId, VIEW_CHANNEL)
+		if err != nil {
+			app.messagingService.QuitChannel(client, check.ChannelId)
+			continue
+		}
+
+		app.messagingService.JoinChannels(client, check.ServerId, []uuid.UUID{check.ChannelId})
+	}
+}
+
+func getChannelMemberKey(channelMember any) (uuid.UUID, uuid.UUID) {
+	switch member := channelMember.(type) {
+	case *entities.ServerChannelMember:
+		return member.ChannelID, member.UserID
+	case *entities.DMChannelMember:
+		return member.ChannelID, member.UserID
+	}
+
+	return uuid.Nil, uuid.Nil
+}
+
+func getChannelId(channel any) uuid.UUID {
+	switch channel := channel.(type) {
+	case *entities.ServerChannel:
+		return channel.ID
+	case *entities.DMChannel:
+		return channel.ID
+	}
+
+	return uuid.Nil
+}
@@ -0,0 +1,61 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMembershipCacheDropsExpiredEntries(t *testing.T) {
+	cache := newMembershipCache()
+	scopeId, userId := uuid.New(), uuid.New()
+
+	cache.set(scopeId, userId, membership{isMember: true})
+	if _, exists := cache.get(scopeId, userId); !exists {
+		t.Fatal("fresh entry was not returned")
+	}
+
+	cache.entries[membershipKey{scopeId, userId}] = membership{isMember: true, expiresAt: time.Now().Add(-time.Second)}
+	if _, exists := cache.get(scopeId, userId); exists {
+		t.Fatal("expired entry was returned")
+	}
+
+	if len(cache.entries) != 0 {
+		t.Errorf("expired entry was kept after a read, %d entries left", len(cache.entries))
+	}
+}
+
+func TestMembershipCacheSweepsOnWrite(t *testing.T) {
+	cache := newMembershipCache()
+
+	for idx := 0; idx < 10; idx++ {
+		cache.entries[membershipKey{uuid.New(), uuid.New()}] = membership{expiresAt: time.Now().Add(-time.Second)}
+	}
+
+	cache.set(uuid.New(), uuid.New(), membership{isMember: true})
+
+	if len(cache.entries) != 1 {
+		t.Errorf("entries = %d after a write, want only the new entry", len(cache.entries))
+	}
+}
+
+func TestMembershipCacheIsBounded(t *testing.T) {
+	cache := newMembershipCache()
+	cache.sweptAt = time.Now()
+
+	for idx := 0; idx < MEMBERSHIP_CACHE_MAX_ENTRIES; idx++ {
+		cache.entries[membershipKey{uuid.New(), uuid.New()}] = membership{expiresAt: time.Now().Add(time.Minute)}
+	}
+
+	scopeId, userId := uuid.New(), uuid.New()
+	cache.set(scopeId, userId, membership{isMember: true})
+
+	if _, exists := cache.get(scopeId, userId); exists {
+		t.Error("entry was cached past the size limit")
+	}
+
+	if len(cache.entries) != MEMBERSHIP_CACHE_MAX_ENTRIES {
+		t.Errorf("entries = %d, want %d", len(cache.entries), MEMBERSHIP_CACHE_MAX_ENTRIES)
+	}
+}
@@ -0,0 +1,125 @@
+package application
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/application/core/msgsrvc"
+	"github.com/critch-app/critch-backend/internal/ports"
+	"github.com/google/uuid"
+)
+
+func (app *App) getMessageMentions(senderId, serverId, channelId uuid.UUID, content string) ([]entities.Mention, error) {
+	mentions := []entities.Mention{}
+
+	var canMentionEveryone *bool
+	for _, mention := range entities.ParseMentions(content) {
+		if mention.TargetType != entities.USER_MENTION && canMentionEveryone == nil {
+			isAllowed, err := app.canMentionEveryone(senderId, serverId, channelId)
+			if err != nil {
+				return nil, err
+			}
+
+			canMentionEveryone = &isAllowed
+		}
+
+		switch mention.TargetType {
+		case entities.USER_MENTION:
+			if !app.isChannelMember(newMentionedChannelMember(serverId, channelId, mention.TargetID)) {
+				continue
+			}
+		case entities.ROLE_MENTION:
+			if serverId == uuid.Nil || !*canMentionEveryone {
+				continue
+			}
+
+			role, err := app.db.GetRole(mention.TargetID)
+			if errors.Is(err, ports.ErrNotFound) {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			if role.ServerID != serverId {
+				continue
+			}
+		case entities.HERE_MENTION, entities.EVERYONE_MENTION:
+			if !*canMentionEveryone {
+				continue
+			}
+
+			if mention.TargetType == entities.HERE_MENTION {
+				userIds, err := app.db.GetOnlineChannelMemberIds(channelId, serverId != uuid.Nil,
+					time.Now().Add(-PRESENCE_TTL))
+				if err != nil {
+					return nil, err
+				}
+
+				for _, userId := range userIds {
+					if userId != senderId {
+						mentions = append(mentions, entities.Mention{TargetType: entities.HERE_MENTION, TargetID: userId})
+					}
+				}
+			}
+		}
+
+		mentions = append(mentions, mention)
+	}
+
+	return mentions, nil
+}
+
+func (app *App) canMentionEveryone(senderId, serverId, channelId uuid.UUID) (bool, error) {
+	if serverId == uuid.Nil {
+		return true, nil
+	}
+
+	channel := &entities.ServerChannel{Channel: entities.Channel{ID: channelId}}
+	err := app.db.GetChannel(channel)
+	if err != nil {
+		return false, err
+	}
+
+	permissions, err := app.getChannelPermissions(channel, senderId)
+	if err != nil {
+		return false, err
+	}
+
+	return permissions.Has(entities.MENTION_EVERYONE), nil
+}
+
+func (app *App) notifyMentions(message any, serverId uuid.UUID) {
+	if len(entities.GetMessageMentions(message)) == 0 {
+		return
+	}
+
+	userIds, err := app.db.GetMentionedUserIds(message)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if len(userIds) == 0 {
+		return
+	}
+
+	app.messagingService.Broadcast <- &msgsrvc.BroadcastMessage{
+		Type:      msgsrvc.MENTION,
+		ChannelId: entities.GetMessageModel(message).ChannelID,
+		ServerId:  serverId,
+		Users:     userIds,
+		Message:   message,
+	}
+}
+
+func newMentionedChannelMember(serverId, channelId, userId uuid.UUID) any {
+	if serverId == uuid.Nil {
+		return &entities.DMChannelMember{ChannelID: channelId, UserID: userId}
+	}
+
+	return &entities.ServerChannelMember{ChannelID: channelId, ServerID: serverId, UserID: userId}
+}
@@ -0,0 +1,136 @@
+package application
+
+import (
+	"log"
+	"time"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/application/core/msgsrvc"
+	"github.com/google/uuid"
+)
+
+// rows not refreshed within three heartbeats belong to a replica that is gone
+const PRESENCE_TTL = 3 * msgsrvc.PRESENCE_CHECK_INTERVAL
+
+func (app *App) TrackPresence() {
+	for update := range app.messagingService.Presence {
+		switch update := update.(type) {
+		case *msgsrvc.PresenceUpdate:
+			app.setInstancePresence(update)
+		case *msgsrvc.PresenceHeartbeat:
+			app.refreshInstancePresence(update)
+		}
+	}
+}
+
+func (app *App) setInstancePresence(update *msgsrvc.PresenceUpdate) {
+	var err error
+	if update.Status == entities.OFFLINE_STATUS {
+		err = app.db.RemoveUserPresence(app.instanceId, update.UserId)
+	} else {
+		err = app.db.SetUserPresence(&entities.UserPresence{
+			InstanceID:  app.instanceId,
+			UserID:      update.UserId,
+			Status:      update.Status,
+			LastSeen:    update.LastSeen,
+			HeartbeatAt: time.Now(),
+		})
+	}
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	app.syncPresence(update.UserId, update.LastSeen)
+}
+
+func (app *App) refreshInstancePresence(heartbeat *msgsrvc.PresenceHeartbeat) {
+	heartbeatAt := time.Now()
+
+	presences := make([]entities.UserPresence, len(heartbeat.Users))
+	for idx, update := range heartbeat.Users {
+		presences[idx] = entities.UserPresence{
+			InstanceID:  app.instanceId,
+			UserID:      update.UserId,
+			Status:      update.Status,
+			LastSeen:    update.LastSeen,
+			HeartbeatAt: heartbeatAt,
+		}
+	}
+
+	userIds, err := app.db.RefreshUserPresences(app.instanceId, &presences, heartbeatAt, heartbeatAt.Add(-PRESENCE_TTL))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, userId := range *userIds {
+		app.syncPresence(userId, heartbeatAt)
+	}
+}
+
+func (app *App) syncPresence(userId uuid.UUID, offlineAt time.Time) {
+	presences, err := app.db.GetUserPresences(userId, time.Now().Add(-PRESENCE_TTL))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	status, lastSeen := aggregatePresence(*presences, offlineAt)
+
+	err = app.db.UpdateUserPresence(userId, status, lastSeen)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	app.publishPresence(userId, status, lastSeen)
+}
+
+func aggregatePresence(presences []entities.UserPresence, offlineAt time.Time) (string, time.Time) {
+	if len(presences) == 0 {
+		return entities.OFFLINE_STATUS, offlineAt
+	}
+
+	status := entities.AWAY_STATUS
+	lastSeen := time.Time{}
+
+	for _, presence := range presences {
+		if presence.Status == entities.ACTIVE_STATUS {
+			status = entities.ACTIVE_STATUS
+		}
+
+		if presence.LastSeen.After(lastSeen) {
+			lastSeen = presence.LastSeen
+		}
+	}
+
+	return status, lastSeen
+}
+
+func (app *App) publishPresence(userId uuid.UUID, status string, lastSeen time.Time) {
+	serverIds, err := app.db.GetUserServerIds(userId)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if len(*serverIds) == 0 {
+		return
+	}
+
+	app.messagingService.Broadcast <- &msgsrvc.BroadcastMessage{
+		Type:    msgsrvc.PRESENCE_UPDATED,
+		UserId:  userId,
+		Servers: *serverIds,
+		Message: map[string]any{
+			"type": msgsrvc.PRESENCE_UPDATED,
+			"data": map[string]any{
+				"user_id":   userId,
+				"status":    status,
+				"last_seen": lastSeen,
+			},
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/critch-app/critch-backend/internal/application/core/entities"
+)
+
+func TestAggregatePresence(t *testing.T) {
+	offlineAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	earlier := offlineAt.Add(-time.Hour)
+	later := offlineAt.Add(-time.Minute)
+
+	tests := []struct {
+		name         string
+		presences    []entities.UserPresence
+		wantStatus   string
+		wantLastSeen time.Time
+	}{
+		{"no instance holds a connection", nil, entities.OFFLINE_STATUS, offlineAt},
+		{
+			"away on every instance",
+			[]entities.UserPresence{
+				{Status: entities.AWAY_STATUS, LastSeen: earlier},
+				{Status: entities.AWAY_STATUS, LastSeen: later},
+			},
+			entities.AWAY_STATUS,
+			later,
+		},
+		{
+			"active on one instance",
+			[]entities.UserPresence{
+				{Status: entities.ACTIVE_STATUS, LastSeen: earlier},
+				{Status: entities.AWAY_STATUS, LastSeen: later},
+			},
+			entities.ACTIVE_STATUS,
+			later,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, lastSeen := aggregatePresence(test.presences, offlineAt)
+
+			if status != test.wantStatus {
+				t.Errorf("status = %s, want %s", status, test.wantStatus)
+			}
+
+			if !lastSeen.Equal(test.wantLastSeen) {
+				t.Errorf("lastSeen = %v, want %v", lastSeen, test.wantLastSeen)
+			}
+		})
+	}
+}
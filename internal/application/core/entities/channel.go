@@ -12,12 +12,33 @@ type Channel struct {
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+const (
+	PUBLIC_CHANNEL    = "public"
+	PRIVATE_CHANNEL   = "private"
+	READ_ONLY_CHANNEL = "read_only"
+)
+
+const (
+	ROLE_OVERRIDE = "role"
+	USER_OVERRIDE = "user"
+)
+
 type ServerChannel struct {
 	Channel  `gorm:"embedded"`
 	ServerID uuid.UUID `json:"server_id" gorm:"not null"`
+	Mode     string    `json:"mode" binding:"omitempty,oneof=public private read_only" gorm:"not null;check:mode IN ('public', 'private', 'read_only');default:public"`
+
+	Messages  []ServerMessage             `gorm:"foreignKey:ChannelID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Members   []ServerChannelMember       `gorm:"foreignKey:ChannelID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Overrides []ChannelPermissionOverride `gorm:"foreignKey:ChannelID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
 
-	Messages []ServerMessage       `gorm:"foreignKey:ChannelID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Members  []ServerChannelMember `gorm:"foreignKey:ChannelID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+type ChannelPermissionOverride struct {
+	ChannelID  uuid.UUID   `json:"channel_id" gorm:"primaryKey"`
+	TargetType string      `json:"target_type" gorm:"not null;check:target_type IN ('role', 'user')"`
+	TargetID   uuid.UUID   `json:"target_id" gorm:"primaryKey"`
+	Allow      Permissions `json:"allow" gorm:"not null;default:0"`
+	Deny       Permissions `json:"deny" gorm:"not null;default:0"`
 }
 
 type DMChannel struct {
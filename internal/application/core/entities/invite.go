@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ServerInvite struct {
+	Code      string     `json:"code" gorm:"primaryKey"`
+	ServerID  uuid.UUID  `json:"server_id" gorm:"not null;index"`
+	CreatorID uuid.UUID  `json:"creator_id" gorm:"not null"`
+	RoleID    *uuid.UUID `json:"role_id"`
+	MaxUses   int        `json:"max_uses" gorm:"not null;default:0"`
+	Uses      int        `json:"uses" gorm:"not null;default:0"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,70 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	USER_MENTION     = "user"
+	ROLE_MENTION     = "role"
+	HERE_MENTION     = "here"
+	EVERYONE_MENTION = "everyone"
+)
+
+var mentionPattern = regexp.MustCompile(`<@(&?)([0-9a-fA-F-]{36})>|(?:^|[^\w<])@(here|everyone)\b`)
+
+type Mention struct {
+	MessageID  uuid.UUID `json:"message_id" gorm:"primaryKey"`
+	TargetType string    `json:"type" gorm:"primaryKey;size:16"`
+	TargetID   uuid.UUID `json:"target_id" gorm:"primaryKey;index"`
+}
+
+type ServerMessageMention struct {
+	Mention `gorm:"embedded"`
+}
+
+type DirectMessageMention struct {
+	Mention `gorm:"embedded"`
+}
+
+// @here rows with a target record who was online when the message was sent
+func isHereRecipient(mention Mention) bool {
+	return mention.TargetType == HERE_MENTION && mention.TargetID != uuid.Nil
+}
+
+func ParseMentions(content string) []Mention {
+	mentions := []Mention{}
+	found := map[Mention]bool{}
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		mention := Mention{}
+
+		switch {
+		case match[3] != "":
+			mention.TargetType = strings.ToLower(match[3])
+		case match[1] != "":
+			mention.TargetType = ROLE_MENTION
+		default:
+			mention.TargetType = USER_MENTION
+		}
+
+		if match[2] != "" {
+			targetId, err := uuid.Parse(match[2])
+			if err != nil {
+				continue
+			}
+
+			mention.TargetID = targetId
+		}
+
+		if !found[mention] {
+			found[mention] = true
+			mentions = append(mentions, mention)
+		}
+	}
+
+	return mentions
+}
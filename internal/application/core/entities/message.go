@@ -2,23 +2,99 @@ package entities
 
 import (
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"time"
 )
 
+const (
+	BEFORE_CURSOR = "before"
+	AFTER_CURSOR  = "after"
+	AROUND_CURSOR = "around"
+)
+
+type MessageCursor struct {
+	Direction string
+	MessageID uuid.UUID
+}
+
 type Message struct {
-	ID         uuid.UUID `json:"id"`
-	ChannelID  uuid.UUID `json:"channel_id" gorm:"not null"`
-	SenderID   uuid.UUID `json:"sender_id" gorm:"not null"`
-	Content    string    `json:"content" gorm:"not null"`
-	Attachment string    `json:"attachment"`
-	SentAt     time.Time `json:"sent_at" gorm:"autoCreateTime"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID          uuid.UUID      `json:"id" gorm:"index:,composite:channel_history,priority:3"`
+	ChannelID   uuid.UUID      `json:"channel_id" gorm:"not null;index:,composite:channel_history,priority:1"`
+	SenderID    uuid.UUID      `json:"sender_id" gorm:"not null;uniqueIndex:,composite:sender_nonce,priority:1"`
+	ParentID    *uuid.UUID     `json:"parent_id" gorm:"index"`
+	Nonce       *string        `json:"nonce,omitempty" gorm:"size:64;uniqueIndex:,composite:sender_nonce,priority:2"`
+	Content     string         `json:"content" gorm:"not null"`
+	Attachment  string         `json:"attachment"`
+	ReplyCount  int            `json:"reply_count" gorm:"not null;default:0"`
+	LastReplyAt *time.Time     `json:"last_reply_at"`
+	SentAt      time.Time      `json:"sent_at" gorm:"autoCreateTime;index:,composite:channel_history,priority:2"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 type ServerMessage struct {
 	Message `gorm:"embedded"`
+
+	Replies   []ServerMessage         `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Reactions []ServerMessageReaction `gorm:"foreignKey:MessageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Revisions []ServerMessageRevision `gorm:"foreignKey:MessageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Mentions  []ServerMessageMention  `gorm:"foreignKey:MessageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 type DirectMessage struct {
 	Message `gorm:"embedded"`
+
+	Replies   []DirectMessage         `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Reactions []DirectMessageReaction `gorm:"foreignKey:MessageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Revisions []DirectMessageRevision `gorm:"foreignKey:MessageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Mentions  []DirectMessageMention  `gorm:"foreignKey:MessageID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+func GetMessageModel(msg any) *Message {
+	switch msg.(type) {
+	case *ServerMessage:
+		return &msg.(*ServerMessage).Message
+	case *DirectMessage:
+		return &msg.(*DirectMessage).Message
+	}
+
+	return nil
+}
+
+func GetMessageMentions(msg any) []Mention {
+	mentions := []Mention{}
+
+	switch msg.(type) {
+	case *ServerMessage:
+		for _, mention := range msg.(*ServerMessage).Mentions {
+			if !isHereRecipient(mention.Mention) {
+				mentions = append(mentions, mention.Mention)
+			}
+		}
+	case *DirectMessage:
+		for _, mention := range msg.(*DirectMessage).Mentions {
+			if !isHereRecipient(mention.Mention) {
+				mentions = append(mentions, mention.Mention)
+			}
+		}
+	}
+
+	return mentions
+}
+
+func SetMessageMentions(msg any, mentions []Mention) {
+	switch msg.(type) {
+	case *ServerMessage:
+		messageModel := msg.(*ServerMessage)
+		messageModel.Mentions = make([]ServerMessageMention, len(mentions))
+		for idx, mention := range mentions {
+			messageModel.Mentions[idx] = ServerMessageMention{Mention: mention}
+		}
+	case *DirectMessage:
+		messageModel := msg.(*DirectMessage)
+		messageModel.Mentions = make([]DirectMessageMention, len(mentions))
+		for idx, mention := range mentions {
+			messageModel.Mentions[idx] = DirectMessageMention{Mention: mention}
+		}
+	}
 }
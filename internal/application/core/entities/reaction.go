@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Reaction struct {
+	MessageID uuid.UUID `json:"message_id" gorm:"primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"primaryKey"`
+	Emoji     string    `json:"emoji" gorm:"primaryKey;size:64"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ServerMessageReaction struct {
+	Reaction `gorm:"embedded"`
+}
+
+type DirectMessageReaction struct {
+	Reaction `gorm:"embedded"`
+}
+
+type ReactionCount struct {
+	MessageID uuid.UUID `json:"message_id"`
+	Emoji     string    `json:"emoji"`
+	Count     int       `json:"count"`
+}
@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ChannelReadState struct {
+	ChannelID         uuid.UUID  `json:"channel_id" gorm:"primaryKey"`
+	UserID            uuid.UUID  `json:"user_id" gorm:"primaryKey"`
+	LastReadMessageID *uuid.UUID `json:"last_read_message_id"`
+	LastReadAt        time.Time  `json:"last_read_at" gorm:"not null"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type UnreadCount struct {
+	ID           uuid.UUID `json:"id"`
+	UnreadCount  int       `json:"unread_count"`
+	MentionCount int       `json:"mention_count"`
+}
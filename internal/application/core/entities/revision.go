@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Revision struct {
+	ID         uuid.UUID `json:"id"`
+	MessageID  uuid.UUID `json:"message_id" gorm:"not null;index"`
+	Content    string    `json:"content" gorm:"not null"`
+	Attachment string    `json:"attachment"`
+	EditedAt   time.Time `json:"edited_at" gorm:"autoCreateTime"`
+}
+
+type ServerMessageRevision struct {
+	Revision `gorm:"embedded"`
+}
+
+type DirectMessageRevision struct {
+	Revision `gorm:"embedded"`
+}
@@ -0,0 +1,97 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Permissions int64
+
+const (
+	MANAGE_SERVER Permissions = 1 << iota
+	MANAGE_ROLES
+	MANAGE_CHANNELS
+	MANAGE_MEMBERS
+	DELETE_MESSAGES
+	MENTION_EVERYONE
+	CREATE_INVITES
+	SEND_MESSAGES
+	VIEW_CHANNELS
+)
+
+const (
+	NO_PERMISSIONS      Permissions = 0
+	DEFAULT_PERMISSIONS             = CREATE_INVITES | SEND_MESSAGES | VIEW_CHANNELS
+	ALL_PERMISSIONS                 = MANAGE_SERVER | MANAGE_ROLES | MANAGE_CHANNELS | MANAGE_MEMBERS |
+		DELETE_MESSAGES | MENTION_EVERYONE | CREATE_INVITES | SEND_MESSAGES | VIEW_CHANNELS
+)
+
+var permissionNames = []struct {
+	name       string
+	permission Permissions
+}{
+	{"manage_server", MANAGE_SERVER},
+	{"manage_roles", MANAGE_ROLES},
+	{"manage_channels", MANAGE_CHANNELS},
+	{"manage_members", MANAGE_MEMBERS},
+	{"delete_messages", DELETE_MESSAGES},
+	{"mention_everyone", MENTION_EVERYONE},
+	{"create_invites", CREATE_INVITES},
+	{"send_messages", SEND_MESSAGES},
+	{"view_channels", VIEW_CHANNELS},
+}
+
+func ParsePermissions(names []string) (Permissions, error) {
+	permissions := NO_PERMISSIONS
+
+	for _, name := range names {
+		found := false
+		for _, permissionName := range permissionNames {
+			if permissionName.name == name {
+				permissions |= permissionName.permission
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return NO_PERMISSIONS, fmt.Errorf("unknown permission: %s", name)
+		}
+	}
+
+	return permissions, nil
+}
+
+func (permissions Permissions) Has(permission Permissions) bool {
+	return permissions&permission == permission
+}
+
+func (permissions Permissions) Names() []string {
+	names := make([]string, 0, len(permissionNames))
+	for _, permissionName := range permissionNames {
+		if permissions.Has(permissionName.permission) {
+			names = append(names, permissionName.name)
+		}
+	}
+
+	return names
+}
+
+type ServerRole struct {
+	ID          uuid.UUID   `json:"id"`
+	ServerID    uuid.UUID   `json:"server_id" gorm:"not null;index"`
+	Name        string      `json:"name" gorm:"not null"`
+	Permissions Permissions `json:"permissions" gorm:"not null;default:0"`
+	Position    int         `json:"position" gorm:"not null;default:1"`
+	CreatedAt   time.Time   `json:"created_at"`
+
+	Members []ServerMemberRole `gorm:"foreignKey:RoleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+type ServerMemberRole struct {
+	ServerID uuid.UUID `json:"server_id" gorm:"primaryKey"`
+	UserID   uuid.UUID `json:"user_id" gorm:"primaryKey"`
+	RoleID   uuid.UUID `json:"role_id" gorm:"primaryKey"`
+}
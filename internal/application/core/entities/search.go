@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type MessageSearch struct {
+	Query         string
+	UserID        uuid.UUID
+	SenderID      *uuid.UUID
+	ChannelID     *uuid.UUID
+	ServerID      *uuid.UUID
+	From          *time.Time
+	To            *time.Time
+	HasAttachment *bool
+	MentionsMe    bool
+	ChannelIDs    []uuid.UUID
+	Offset        int
+	Limit         int
+}
+
+type MessageSearchResult struct {
+	ID         uuid.UUID  `json:"id"`
+	ChannelID  uuid.UUID  `json:"channel_id"`
+	ServerID   *uuid.UUID `json:"server_id"`
+	SenderID   uuid.UUID  `json:"sender_id"`
+	ParentID   *uuid.UUID `json:"parent_id"`
+	Content    string     `json:"content"`
+	Attachment string     `json:"attachment"`
+	SentAt     time.Time  `json:"sent_at"`
+	Snippet    string     `json:"snippet"`
+	Rank       float64    `json:"rank"`
+}
@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Session struct {
+	ID               uuid.UUID  `json:"id"`
+	UserID           uuid.UUID  `json:"user_id" gorm:"not null;index"`
+	RefreshTokenHash string     `json:"-" gorm:"not null"`
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
@@ -5,23 +5,39 @@ import (
 	"time"
 )
 
+const (
+	ACTIVE_STATUS  = "active"
+	AWAY_STATUS    = "away"
+	OFFLINE_STATUS = "offline"
+)
+
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	FirstName string    `json:"first_name" gorm:"not null"`
-	LastName  string    `json:"last_name" gorm:"not null"`
-	Email     string    `json:"email" gorm:"unique;not null"`
-	Password  string    `json:"password" gorm:"not null"`
-	Status    string    `json:"status" gorm:"not null;check:status IN ('active', 'away');default:active"`
-	Photo     string    `json:"photo"`
-	Phone     string    `json:"phone" gorm:"unique;not null"`
-	TimeZone  string    `json:"time_zone" gorm:"not null"`
-	LastSeen  string    `json:"last_seen"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID  `json:"id"`
+	FirstName string     `json:"first_name" gorm:"not null"`
+	LastName  string     `json:"last_name" gorm:"not null"`
+	Email     string     `json:"email" gorm:"unique;not null"`
+	Password  string     `json:"password" gorm:"not null"`
+	Status    string     `json:"status" gorm:"not null;check:chk_users_presence,status IN ('active', 'away', 'offline');default:offline"`
+	Photo     string     `json:"photo"`
+	Phone     string     `json:"phone" gorm:"unique;not null"`
+	TimeZone  string     `json:"time_zone" gorm:"not null"`
+	LastSeen  *time.Time `json:"last_seen"`
+	CreatedAt time.Time  `json:"created_at"`
 
 	DirectMessages []DirectMessage   `gorm:"foreignKey:SenderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	ServerMessages []ServerMessage   `gorm:"foreignKey:SenderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Servers        []ServerMember    `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	DMChannels     []DMChannelMember `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Sessions       []Session         `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Presences      []UserPresence    `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+type UserPresence struct {
+	InstanceID  uuid.UUID `json:"instance_id" gorm:"primaryKey"`
+	UserID      uuid.UUID `json:"user_id" gorm:"primaryKey"`
+	Status      string    `json:"status" gorm:"not null"`
+	LastSeen    time.Time `json:"last_seen" gorm:"not null"`
+	HeartbeatAt time.Time `json:"heartbeat_at" gorm:"not null;index"`
 }
 
 type ServerMember struct {
@@ -31,6 +47,7 @@ type ServerMember struct {
 	JoinedAt time.Time `json:"joined_at" gorm:"autoCreateTime"`
 
 	Channels []ServerChannelMember `gorm:"foreignKey:UserID, ServerID;references:UserID, ServerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Roles    []ServerMemberRole    `gorm:"foreignKey:UserID, ServerID;references:UserID, ServerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
 
 type DMChannelMember struct {
@@ -1,38 +1,109 @@
 package msgsrvc
 
 import (
+	"encoding/json"
+	"log"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"time"
+
 	"github.com/critch-app/critch-backend/internal/application/core/entities"
+	"github.com/critch-app/critch-backend/internal/ports"
 	"github.com/google/uuid"
 )
 
 type MessagingService struct {
-	ServerClients  map[uuid.UUID]map[uuid.UUID]*Client
-	ChannelClients map[uuid.UUID]map[uuid.UUID]*Client
-	Connect        chan *NewClient
-	Disconnect     chan *Client
-	Broadcast      chan *BroadcastMessage
+	QueueSize         int
+	SlowClientPolicy  string
+	EventLogSize      int
+	DroppedMessages   atomic.Int64
+	EvictedClients    atomic.Int64
+	ResyncedClients   atomic.Int64
+	ServerClients     map[uuid.UUID]map[uuid.UUID]*Client
+	ChannelClients    map[uuid.UUID]map[uuid.UUID]*Client
+	SessionClients    map[uuid.UUID]map[uuid.UUID]*Client
+	UserClients       map[uuid.UUID]map[uuid.UUID]*Client
+	TypingClients     map[uuid.UUID]map[uuid.UUID]time.Time
+	ActiveClients     map[uuid.UUID]map[uuid.UUID]time.Time
+	UserStatus        map[uuid.UUID]string
+	Connect           chan *NewClient
+	Disconnect        chan *Client
+	Broadcast         chan *BroadcastMessage
+	Subscribe         chan *Subscription
+	Unsubscribe       chan *Subscription
+	Activity          chan *Client
+	Presence          chan any
+	MembershipChanges chan any
+	StreamFrames      chan *BroadcastMessage
+
+	broker  ports.Broker
+	inbound chan *BroadcastMessage
+	seq     uint64
+	events  []*loggedEvent
 }
 
-func NewService() *MessagingService {
+func NewService(config Config) *MessagingService {
+	if config.QueueSize <= 0 {
+		config.QueueSize = DEFAULT_QUEUE_SIZE
+	}
+
+	if config.EventLogSize <= 0 {
+		config.EventLogSize = DEFAULT_EVENT_LOG_SIZE
+	}
+
+	switch config.SlowClientPolicy {
+	case DROP_POLICY, DISCONNECT_POLICY, RESYNC_POLICY:
+	default:
+		config.SlowClientPolicy = DROP_POLICY
+	}
+
 	return &MessagingService{
-		ServerClients:  make(map[uuid.UUID]map[uuid.UUID]*Client),
-		ChannelClients: make(map[uuid.UUID]map[uuid.UUID]*Client),
-		Connect:        make(chan *NewClient),
-		Disconnect:     make(chan *Client),
-		Broadcast:      make(chan *BroadcastMessage, 10),
+		QueueSize:         config.QueueSize,
+		SlowClientPolicy:  config.SlowClientPolicy,
+		EventLogSize:      config.EventLogSize,
+		ServerClients:     make(map[uuid.UUID]map[uuid.UUID]*Client),
+		ChannelClients:    make(map[uuid.UUID]map[uuid.UUID]*Client),
+		SessionClients:    make(map[uuid.UUID]map[uuid.UUID]*Client),
+		UserClients:       make(map[uuid.UUID]map[uuid.UUID]*Client),
+		TypingClients:     make(map[uuid.UUID]map[uuid.UUID]time.Time),
+		ActiveClients:     make(map[uuid.UUID]map[uuid.UUID]time.Time),
+		UserStatus:        make(map[uuid.UUID]string),
+		Connect:           make(chan *NewClient),
+		Disconnect:        make(chan *Client),
+		Broadcast:         make(chan *BroadcastMessage, 10),
+		Subscribe:         make(chan *Subscription),
+		Unsubscribe:       make(chan *Subscription),
+		Activity:          make(chan *Client, 10),
+		Presence:          make(chan any, 100),
+		MembershipChanges: make(chan any, 100),
+		StreamFrames:      make(chan *BroadcastMessage, 100),
+		broker:            config.Broker,
+		inbound:           make(chan *BroadcastMessage, 10),
 	}
 }
 
 func (srvc *MessagingService) Run() {
+	go srvc.publishBroadcasts()
+	go srvc.receiveBroadcasts()
+
+	typingTicker := time.NewTicker(TYPING_CHECK_INTERVAL)
+	defer typingTicker.Stop()
+
+	presenceTicker := time.NewTicker(PRESENCE_CHECK_INTERVAL)
+	defer presenceTicker.Stop()
+
 	for {
 		select {
 		case newClient := <-srvc.Connect:
+			client := newClient.ClientObj
+
 			for _, serverId := range *newClient.Servers {
 				if srvc.ServerClients[serverId] == nil {
 					srvc.ServerClients[serverId] = make(map[uuid.UUID]*Client)
 				}
 
-				srvc.ServerClients[serverId][newClient.ClientObj.ID] = newClient.ClientObj
+				srvc.ServerClients[serverId][client.ConnectionID] = client
 			}
 
 			for _, channelId := range *newClient.Channels {
@@ -40,110 +111,689 @@ func (srvc *MessagingService) Run() {
 					srvc.ChannelClients[channelId] = make(map[uuid.UUID]*Client)
 				}
 
-				srvc.ChannelClients[channelId][newClient.ClientObj.ID] = newClient.ClientObj
+				srvc.ChannelClients[channelId][client.ConnectionID] = client
 			}
 
-			//srvc.Broadcast <- &BroadcastMessage{
-			//	Type: NOTIFICATION,
-			//	Message: map[string]any{
-			//		"type": LOGGED_IN,
-			//		"data": map[string]any{
-			//			"sender_id": newClient.ClientObj.ID,
-			//		},
-			//	},
-			//}
+			if srvc.SessionClients[client.SessionID] == nil {
+				srvc.SessionClients[client.SessionID] = make(map[uuid.UUID]*Client)
+			}
+
+			srvc.SessionClients[client.SessionID][client.ConnectionID] = client
+
+			if srvc.UserClients[client.ID] == nil {
+				srvc.UserClients[client.ID] = make(map[uuid.UUID]*Client)
+			}
+
+			srvc.UserClients[client.ID][client.ConnectionID] = client
+
+			if srvc.ActiveClients[client.ID] == nil {
+				srvc.ActiveClients[client.ID] = make(map[uuid.UUID]time.Time)
+			}
+
+			srvc.ActiveClients[client.ID][client.ConnectionID] = time.Now()
+			srvc.updatePresence(client.ID)
+
+			if newClient.IsResumed {
+				srvc.replayEvents(newClient)
+			}
 		case client := <-srvc.Disconnect:
-			for _, server := range srvc.ServerClients {
-				delete(server, client.ID)
-				//srvc.Broadcast <- &BroadcastMessage{
-				//	Type: NOTIFICATION,
-				//	Message: map[string]any{
-				//		"type": LOGGED_OUT,
-				//		"data": map[string]any{
-				//			"sender_id": client.ID,
-				//		},
-				//	},
-				//}
+			srvc.removeClient(client)
+		case subscription := <-srvc.Subscribe:
+			srvc.subscribe(subscription.ClientObj, subscription.ServerId, subscription.Channels)
+		case subscription := <-srvc.Unsubscribe:
+			srvc.unsubscribe(subscription.ClientObj, subscription.ServerId, subscription.Channels)
+		case client := <-srvc.Activity:
+			if _, ok := srvc.ActiveClients[client.ID][client.ConnectionID]; ok {
+				srvc.ActiveClients[client.ID][client.ConnectionID] = time.Now()
+				srvc.updatePresence(client.ID)
+			}
+		case <-presenceTicker.C:
+			for userId := range srvc.ActiveClients {
+				srvc.updatePresence(userId)
 			}
 
-			for _, channel := range srvc.ChannelClients {
-				delete(channel, client.ID)
+			srvc.sendPresenceHeartbeat()
+		case now := <-typingTicker.C:
+			for channelId, channel := range srvc.TypingClients {
+				for clientId, expiresAt := range channel {
+					if now.After(expiresAt) {
+						srvc.stopTyping(channelId, clientId)
+					}
+				}
 			}
-		case message := <-srvc.Broadcast:
+		case message := <-srvc.inbound:
 			if message.Type == NOTIFICATION {
+				event := srvc.recordEvent(message, message.Message)
 				server := srvc.ServerClients[message.ServerId]
 				for _, client := range server {
-					client.MessagingChannel <- message.Message
-				}
-			} else if message.Type == MESSAGE {
-				outgoingMessage := map[string]any{
-					"server_id": message.ServerId,
+					srvc.deliver(client, event)
 				}
-				if message.ServerId == uuid.Nil {
-					outgoingMessage["server_id"] = nil
-				}
-
-				switch message.Message.(type) {
-				case *entities.ServerMessage:
-					messageModel := message.Message.(*entities.ServerMessage)
-					outgoingMessage["id"] = messageModel.ID
-					outgoingMessage["channel_id"] = messageModel.ChannelID
-					outgoingMessage["sender_id"] = messageModel.SenderID
-					outgoingMessage["content"] = messageModel.Content
-					outgoingMessage["attachment"] = messageModel.Attachment
-					outgoingMessage["sent_at"] = messageModel.SentAt
-					outgoingMessage["updated_at"] = messageModel.UpdatedAt
-				case *entities.DirectMessage:
-					messageModel := message.Message.(*entities.DirectMessage)
-					outgoingMessage["id"] = messageModel.ID
-					outgoingMessage["channel_id"] = messageModel.ChannelID
-					outgoingMessage["sender_id"] = messageModel.SenderID
-					outgoingMessage["content"] = messageModel.Content
-					outgoingMessage["attachment"] = messageModel.Attachment
-					outgoingMessage["sent_at"] = messageModel.SentAt
-					outgoingMessage["updated_at"] = messageModel.UpdatedAt
+			} else if message.Type == MESSAGE || message.Type == MESSAGE_UPDATED || message.Type == CHANNEL_EVENT {
+				if message.Type == MESSAGE {
+					srvc.stopTyping(message.ChannelId, message.SenderId)
 				}
 
+				event := srvc.recordEvent(message, message.Message)
 				channel := srvc.ChannelClients[message.ChannelId]
 				for _, client := range channel {
-					client.MessagingChannel <- map[string]any{
-						"type": MESSAGE,
-						"data": outgoingMessage,
+					srvc.deliver(client, event)
+				}
+			} else if message.Type == MENTION {
+				event := srvc.recordEvent(message, message.Message)
+				for _, userId := range message.Users {
+					for _, client := range srvc.UserClients[userId] {
+						srvc.deliver(client, event)
 					}
 				}
+			} else if message.Type == USER_EVENT {
+				event := srvc.recordEvent(message, message.Message)
+				user := srvc.UserClients[message.UserId]
+				for _, client := range user {
+					srvc.deliver(client, event)
+				}
+			} else if message.Type == DOMAIN_EVENT {
+				srvc.dispatchDomainEvent(message)
+			} else if message.Type == SESSION_REVOKED {
+				srvc.revokeSessions(message.Sessions)
+			} else if message.Type == TYPING_START {
+				srvc.startTyping(message.ChannelId, message.SenderId)
+			} else if message.Type == TYPING_STOP {
+				srvc.stopTyping(message.ChannelId, message.SenderId)
+			} else if message.Type == PRESENCE_UPDATED {
+				srvc.sendPresence(message)
+			} else if message.Type == MEMBERSHIP_CHANGED {
+				select {
+				case srvc.MembershipChanges <- message:
+				default:
+					log.Println("membership change dropped for user: ", message.UserId)
+				}
+			} else if message.Type == STREAM_FRAME {
+				select {
+				case srvc.StreamFrames <- message:
+				default:
+					log.Println("stream frame dropped for connection: ", message.ConnectionId)
+				}
+			} else if message.Type == ACCESS_CHANGED {
+				for _, channelId := range message.Channels {
+					srvc.checkAccess(message.ServerId, channelId, message.UserId, srvc.ChannelClients[channelId])
+				}
 			}
 		}
 	}
 }
 
-func (srvc *MessagingService) JoinChannels(clientObj *Client, serverId uuid.UUID, channels []uuid.UUID) {
-	if srvc.ServerClients[serverId] == nil {
-		srvc.ServerClients[serverId] = make(map[uuid.UUID]*Client)
+func (srvc *MessagingService) publish(message *BroadcastMessage) {
+	select {
+	case srvc.Broadcast <- message:
+	default:
+		log.Println("broadcast dropped: ", message.Type)
+	}
+}
+
+func (srvc *MessagingService) Publish(message *BroadcastMessage) bool {
+	timeout := time.NewTimer(PUBLISH_TIMEOUT)
+	defer timeout.Stop()
+
+	select {
+	case srvc.Broadcast <- message:
+		return true
+	case <-timeout.C:
+		log.Println("broadcast timed out: ", message.Type)
+		return false
+	}
+}
+
+func (srvc *MessagingService) publishBroadcasts() {
+	for message := range srvc.Broadcast {
+		if message.Type == MESSAGE || message.Type == MESSAGE_UPDATED || message.Type == MENTION {
+			message.SenderId, message.Message = newMessagePayload(message)
+		}
+
+		if isSequenced(message.Type) {
+			seq, err := srvc.broker.NextSeq()
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			message.Seq = seq
+		}
+
+		data, err := json.Marshal(message)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		err = srvc.broker.Publish(data)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func (srvc *MessagingService) receiveBroadcasts() {
+	for data := range srvc.broker.Messages() {
+		message := &BroadcastMessage{}
+
+		err := json.Unmarshal(data, message)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		srvc.inbound <- message
+	}
+}
+
+func newMessagePayload(message *BroadcastMessage) (uuid.UUID, map[string]any) {
+	outgoingMessage := map[string]any{
+		"server_id": message.ServerId,
+	}
+	if message.ServerId == uuid.Nil {
+		outgoingMessage["server_id"] = nil
+	}
+
+	senderId := uuid.Nil
+
+	messageModel := entities.GetMessageModel(message.Message)
+	if messageModel != nil {
+		senderId = messageModel.SenderID
+
+		outgoingMessage["id"] = messageModel.ID
+		outgoingMessage["channel_id"] = messageModel.ChannelID
+		outgoingMessage["sender_id"] = messageModel.SenderID
+		outgoingMessage["parent_id"] = messageModel.ParentID
+		outgoingMessage["nonce"] = messageModel.Nonce
+		outgoingMessage["thread_id"] = messageModel.ParentID
+		outgoingMessage["content"] = messageModel.Content
+		outgoingMessage["attachment"] = messageModel.Attachment
+		outgoingMessage["reply_count"] = messageModel.ReplyCount
+		outgoingMessage["last_reply_at"] = messageModel.LastReplyAt
+		outgoingMessage["sent_at"] = messageModel.SentAt
+		outgoingMessage["updated_at"] = messageModel.UpdatedAt
+		outgoingMessage["mentions"] = entities.GetMessageMentions(message.Message)
+	}
+
+	return senderId, map[string]any{
+		"type": message.Type,
+		"data": outgoingMessage,
+	}
+}
+
+func isSequenced(messageType string) bool {
+	switch messageType {
+	case NOTIFICATION, MESSAGE, MESSAGE_UPDATED, CHANNEL_EVENT, MENTION, USER_EVENT, DOMAIN_EVENT:
+		return true
+	}
+
+	return false
+}
+
+func (srvc *MessagingService) recordEvent(message *BroadcastMessage, payload any) map[string]any {
+	if message.Seq > srvc.seq {
+		srvc.seq = message.Seq
+	}
+
+	event, ok := payload.(map[string]any)
+	if !ok {
+		event = map[string]any{}
+		data, err := json.Marshal(payload)
+		if err == nil {
+			json.Unmarshal(data, &event)
+		}
+	}
+
+	sequencedEvent := make(map[string]any, len(event)+1)
+	for key, value := range event {
+		sequencedEvent[key] = value
+	}
+
+	sequencedEvent["seq"] = message.Seq
+
+	srvc.events = append(srvc.events, &loggedEvent{
+		Seq:       message.Seq,
+		Type:      message.Type,
+		ServerId:  message.ServerId,
+		ChannelId: message.ChannelId,
+		UserId:    message.UserId,
+		Users:     message.Users,
+		Event:     sequencedEvent,
+	})
+
+	if len(srvc.events) > srvc.EventLogSize {
+		srvc.events = append([]*loggedEvent(nil), srvc.events[len(srvc.events)-srvc.EventLogSize:]...)
+	}
+
+	return sequencedEvent
+}
+
+func (srvc *MessagingService) replayEvents(newClient *NewClient) {
+	client := newClient.ClientObj
+
+	oldestSeq := srvc.seq + 1
+	for _, event := range srvc.events {
+		oldestSeq = min(oldestSeq, event.Seq)
+	}
+
+	if newClient.LastSeq > srvc.seq || (newClient.LastSeq < srvc.seq && newClient.LastSeq+1 < oldestSeq) {
+		srvc.requestResync(client)
+		return
+	}
+
+	servers := make(map[uuid.UUID]bool, len(*newClient.Servers))
+	for _, serverId := range *newClient.Servers {
+		servers[serverId] = true
+	}
+
+	channels := make(map[uuid.UUID]bool, len(*newClient.Channels))
+	for _, channelId := range *newClient.Channels {
+		channels[channelId] = true
+	}
+
+	missedEvents := []map[string]any{}
+	for _, event := range srvc.events {
+		if event.Seq <= newClient.LastSeq {
+			continue
+		}
+
+		if (event.Type == NOTIFICATION && servers[event.ServerId]) ||
+			(event.Type == USER_EVENT && event.UserId == client.ID) ||
+			(event.Type == DOMAIN_EVENT && isDomainEventRecipient(event, client.ID, servers, channels)) ||
+			(event.Type == MENTION && slices.Contains(event.Users, client.ID)) ||
+			(event.Type != NOTIFICATION && event.Type != USER_EVENT && event.Type != DOMAIN_EVENT &&
+				event.Type != MENTION && channels[event.ChannelId]) {
+			missedEvents = append(missedEvents, event.Event)
+		}
+	}
+
+	if len(missedEvents) > cap(client.MessagingChannel)-len(client.MessagingChannel) {
+		srvc.requestResync(client)
+		return
+	}
+
+	for _, event := range missedEvents {
+		srvc.deliver(client, event)
+	}
+}
+
+func isDomainEventRecipient(event *loggedEvent, userId uuid.UUID, servers, channels map[uuid.UUID]bool) bool {
+	if event.UserId == userId {
+		return true
+	}
+
+	if event.ServerId != uuid.Nil {
+		return servers[event.ServerId]
+	}
+
+	return channels[event.ChannelId]
+}
+
+func (srvc *MessagingService) dispatchDomainEvent(message *BroadcastMessage) {
+	event := srvc.recordEvent(message, message.Message)
+
+	recipients := make(map[uuid.UUID]*Client)
+	if message.ServerId != uuid.Nil {
+		maps.Copy(recipients, srvc.ServerClients[message.ServerId])
+	} else {
+		maps.Copy(recipients, srvc.ChannelClients[message.ChannelId])
+	}
+
+	if message.UserId != uuid.Nil {
+		maps.Copy(recipients, srvc.UserClients[message.UserId])
+	}
+
+	for _, client := range recipients {
+		srvc.deliver(client, event)
+	}
+
+	switch message.Event {
+	case MEMBER_JOINED:
+		for _, client := range srvc.UserClients[message.UserId] {
+			srvc.subscribe(client, message.ServerId, message.Channels)
+		}
+	case MEMBER_REMOVED:
+		for _, client := range srvc.UserClients[message.UserId] {
+			srvc.unsubscribe(client, message.ServerId, message.Channels)
+		}
+	case CHANNEL_CREATED:
+		for _, client := range srvc.UserClients[message.UserId] {
+			srvc.subscribe(client, message.ServerId, []uuid.UUID{message.ChannelId})
+		}
+
+		if message.ServerId != uuid.Nil {
+			srvc.checkAccess(message.ServerId, message.ChannelId, uuid.Nil, srvc.ServerClients[message.ServerId])
+		}
+	case CHANNEL_DELETED:
+		srvc.removeSubscriptions(uuid.Nil, []uuid.UUID{message.ChannelId})
+	case SERVER_DELETED:
+		srvc.removeSubscriptions(message.ServerId, message.Channels)
+	}
+}
+
+func (srvc *MessagingService) checkAccess(serverId, channelId, userId uuid.UUID, candidates map[uuid.UUID]*Client) {
+	check := &AccessCheck{
+		ServerId:  serverId,
+		ChannelId: channelId,
+	}
+
+	for _, client := range candidates {
+		if userId == uuid.Nil || client.ID == userId {
+			check.Clients = append(check.Clients, client)
+		}
+	}
+
+	if len(check.Clients) == 0 {
+		return
 	}
 
-	srvc.ServerClients[serverId][clientObj.ID] = clientObj
+	select {
+	case srvc.MembershipChanges <- check:
+	default:
+		log.Println("access check dropped for channel: ", channelId)
+	}
+}
+
+func (srvc *MessagingService) subscribe(client *Client, serverId uuid.UUID, channels []uuid.UUID) {
+	if _, ok := srvc.UserClients[client.ID][client.ConnectionID]; !ok {
+		return
+	}
+
+	if serverId != uuid.Nil {
+		if srvc.ServerClients[serverId] == nil {
+			srvc.ServerClients[serverId] = make(map[uuid.UUID]*Client)
+		}
+
+		srvc.ServerClients[serverId][client.ConnectionID] = client
+	}
 
 	for _, channelId := range channels {
 		if srvc.ChannelClients[channelId] == nil {
 			srvc.ChannelClients[channelId] = make(map[uuid.UUID]*Client)
 		}
 
-		srvc.ChannelClients[channelId][clientObj.ID] = clientObj
+		srvc.ChannelClients[channelId][client.ConnectionID] = client
 	}
 }
 
-func (srvc *MessagingService) QuitChannel(clientObj *Client, channelId uuid.UUID) {
-	delete(srvc.ChannelClients[channelId], clientObj.ID)
+func (srvc *MessagingService) unsubscribe(client *Client, serverId uuid.UUID, channels []uuid.UUID) {
+	for _, channelId := range channels {
+		delete(srvc.ChannelClients[channelId], client.ConnectionID)
+		if len(srvc.ChannelClients[channelId]) == 0 {
+			delete(srvc.ChannelClients, channelId)
+		}
+	}
+
+	if serverId != uuid.Nil {
+		delete(srvc.ServerClients[serverId], client.ConnectionID)
+		if len(srvc.ServerClients[serverId]) == 0 {
+			delete(srvc.ServerClients, serverId)
+		}
+	}
+}
+
+func (srvc *MessagingService) removeSubscriptions(serverId uuid.UUID, channels []uuid.UUID) {
+	for _, channelId := range channels {
+		delete(srvc.ChannelClients, channelId)
+		delete(srvc.TypingClients, channelId)
+	}
+
+	if serverId != uuid.Nil {
+		delete(srvc.ServerClients, serverId)
+	}
+}
+
+func (srvc *MessagingService) requestResync(client *Client) {
+	if !srvc.trySend(client, map[string]any{"type": RESYNC_REQUIRED, "data": map[string]any{"seq": srvc.seq}}) {
+		client.needsResync = true
+	}
+}
+
+func (srvc *MessagingService) deliver(client *Client, message any) {
+	if client.needsResync {
+		if !srvc.trySend(client, map[string]any{"type": RESYNC_REQUIRED, "data": map[string]any{"seq": srvc.seq}}) {
+			srvc.DroppedMessages.Add(1)
+			return
+		}
+
+		client.needsResync = false
+	}
+
+	if srvc.trySend(client, message) {
+		return
+	}
+
+	srvc.DroppedMessages.Add(1)
+
+	switch srvc.SlowClientPolicy {
+	case DISCONNECT_POLICY:
+		srvc.EvictedClients.Add(1)
+		log.Println("evicting slow client: ", client.ConnectionID)
+
+		srvc.removeClient(client)
+		client.Close()
+	case RESYNC_POLICY:
+		if !client.needsResync {
+			srvc.ResyncedClients.Add(1)
+			client.needsResync = true
+		}
+	}
 }
 
-func (srvc *MessagingService) QuitServer(clientObj *Client, serverId uuid.UUID) {
-	delete(srvc.ServerClients[serverId], clientObj.ID)
+func (srvc *MessagingService) trySend(client *Client, message any) bool {
+	select {
+	case client.MessagingChannel <- message:
+		return true
+	default:
+		return false
+	}
 }
 
-func (srvc *MessagingService) RemoveChannel(channelId uuid.UUID) {
-	delete(srvc.ChannelClients, channelId)
+func (srvc *MessagingService) Stats() *Stats {
+	return &Stats{
+		DroppedMessages: srvc.DroppedMessages.Load(),
+		EvictedClients:  srvc.EvictedClients.Load(),
+		ResyncedClients: srvc.ResyncedClients.Load(),
+	}
 }
 
-func (srvc *MessagingService) RemoveServer(serverId uuid.UUID) {
-	delete(srvc.ServerClients, serverId)
+func (srvc *MessagingService) revokeSessions(sessionIds []uuid.UUID) {
+	for _, sessionId := range sessionIds {
+		for _, client := range srvc.SessionClients[sessionId] {
+			srvc.removeClient(client)
+
+			isSent := srvc.trySend(client, map[string]any{
+				"type": SESSION_REVOKED,
+				"data": map[string]any{
+					"session_id": sessionId,
+				},
+			})
+
+			if !isSent {
+				client.Close()
+			}
+		}
+	}
+}
+
+func (srvc *MessagingService) removeClient(client *Client) {
+	delete(srvc.ActiveClients[client.ID], client.ConnectionID)
+	srvc.updatePresence(client.ID)
+
+	for _, server := range srvc.ServerClients {
+		delete(server, client.ConnectionID)
+	}
+
+	for _, channel := range srvc.ChannelClients {
+		delete(channel, client.ConnectionID)
+	}
+
+	delete(srvc.SessionClients[client.SessionID], client.ConnectionID)
+	if len(srvc.SessionClients[client.SessionID]) == 0 {
+		delete(srvc.SessionClients, client.SessionID)
+	}
+
+	delete(srvc.UserClients[client.ID], client.ConnectionID)
+	if len(srvc.UserClients[client.ID]) == 0 {
+		delete(srvc.UserClients, client.ID)
+
+		for channelId, channel := range srvc.TypingClients {
+			if _, isTyping := channel[client.ID]; !isTyping {
+				continue
+			}
+
+			srvc.stopTyping(channelId, client.ID)
+			srvc.publish(&BroadcastMessage{
+				Type:      TYPING_STOP,
+				ChannelId: channelId,
+				SenderId:  client.ID,
+			})
+		}
+	}
+}
+
+func (srvc *MessagingService) getPresence(userId uuid.UUID) (string, time.Time) {
+	if len(srvc.ActiveClients[userId]) == 0 {
+		return entities.OFFLINE_STATUS, time.Now()
+	}
+
+	lastSeen := time.Time{}
+	for _, lastActivity := range srvc.ActiveClients[userId] {
+		if lastActivity.After(lastSeen) {
+			lastSeen = lastActivity
+		}
+	}
+
+	if time.Since(lastSeen) < IDLE_TIMEOUT {
+		return entities.ACTIVE_STATUS, lastSeen
+	}
+
+	return entities.AWAY_STATUS, lastSeen
+}
+
+func (srvc *MessagingService) updatePresence(userId uuid.UUID) {
+	status, lastSeen := srvc.getPresence(userId)
+
+	currentStatus, isTracked := srvc.UserStatus[userId]
+	if currentStatus == status || (!isTracked && status == entities.OFFLINE_STATUS) {
+		return
+	}
+
+	if status == entities.OFFLINE_STATUS {
+		delete(srvc.ActiveClients, userId)
+		delete(srvc.UserStatus, userId)
+	} else {
+		srvc.UserStatus[userId] = status
+	}
+
+	select {
+	case srvc.Presence <- &PresenceUpdate{
+		UserId:   userId,
+		Status:   status,
+		LastSeen: lastSeen,
+	}:
+	default:
+		log.Println("presence update dropped for user: ", userId)
+	}
+}
+
+func (srvc *MessagingService) sendPresenceHeartbeat() {
+	heartbeat := &PresenceHeartbeat{
+		Users: make([]PresenceUpdate, 0, len(srvc.UserStatus)),
+	}
+
+	for userId := range srvc.UserStatus {
+		status, lastSeen := srvc.getPresence(userId)
+		heartbeat.Users = append(heartbeat.Users, PresenceUpdate{
+			UserId:   userId,
+			Status:   status,
+			LastSeen: lastSeen,
+		})
+	}
+
+	select {
+	case srvc.Presence <- heartbeat:
+	default:
+		log.Println("presence heartbeat dropped")
+	}
+}
+
+func (srvc *MessagingService) sendPresence(message *BroadcastMessage) {
+	sent := make(map[uuid.UUID]bool)
+	for _, serverId := range message.Servers {
+		for _, client := range srvc.ServerClients[serverId] {
+			if client.ID == message.UserId || sent[client.ConnectionID] {
+				continue
+			}
+
+			sent[client.ConnectionID] = true
+			srvc.deliver(client, message.Message)
+		}
+	}
+}
+
+func (srvc *MessagingService) startTyping(channelId, clientId uuid.UUID) {
+	if srvc.TypingClients[channelId] == nil {
+		srvc.TypingClients[channelId] = make(map[uuid.UUID]time.Time)
+	}
+
+	_, isTyping := srvc.TypingClients[channelId][clientId]
+	srvc.TypingClients[channelId][clientId] = time.Now().Add(TYPING_TIMEOUT)
+
+	if !isTyping {
+		srvc.sendTyping(TYPING_START, channelId, clientId)
+	}
+}
+
+func (srvc *MessagingService) stopTyping(channelId, clientId uuid.UUID) {
+	_, isTyping := srvc.TypingClients[channelId][clientId]
+	if !isTyping {
+		return
+	}
+
+	delete(srvc.TypingClients[channelId], clientId)
+	if len(srvc.TypingClients[channelId]) == 0 {
+		delete(srvc.TypingClients, channelId)
+	}
+
+	srvc.sendTyping(TYPING_STOP, channelId, clientId)
+}
+
+func (srvc *MessagingService) sendTyping(typingType string, channelId, senderId uuid.UUID) {
+	for _, client := range srvc.ChannelClients[channelId] {
+		if client.ID == senderId {
+			continue
+		}
+
+		srvc.deliver(client, map[string]any{
+			"type": typingType,
+			"data": map[string]any{
+				"channel_id": channelId,
+				"user_id":    senderId,
+			},
+		})
+	}
+}
+
+func (srvc *MessagingService) JoinChannels(clientObj *Client, serverId uuid.UUID, channels []uuid.UUID) {
+	srvc.Subscribe <- &Subscription{
+		ClientObj: clientObj,
+		ServerId:  serverId,
+		Channels:  channels,
+	}
+}
+
+func (srvc *MessagingService) QuitChannel(clientObj *Client, channelId uuid.UUID) {
+	srvc.Unsubscribe <- &Subscription{
+		ClientObj: clientObj,
+		Channels:  []uuid.UUID{channelId},
+	}
+}
+
+func (srvc *MessagingService) QuitServer(clientObj *Client, serverId uuid.UUID, channels []uuid.UUID) {
+	srvc.Unsubscribe <- &Subscription{
+		ClientObj: clientObj,
+		ServerId:  serverId,
+		Channels:  channels,
+	}
 }
@@ -0,0 +1,385 @@
+package msgsrvc
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const RECEIVE_TIMEOUT = 2 * time.Second
+
+type fakeBroker struct {
+	messages chan []byte
+	seq      atomic.Uint64
+}
+
+func (fb *fakeBroker) NextSeq() (uint64, error) {
+	return fb.seq.Add(1), nil
+}
+
+func (fb *fakeBroker) Publish(payload []byte) error {
+	fb.messages <- payload
+
+	return nil
+}
+
+func (fb *fakeBroker) Messages() <-chan []byte {
+	return fb.messages
+}
+
+func (fb *fakeBroker) Close() error {
+	close(fb.messages)
+
+	return nil
+}
+
+func newTestService(t *testing.T) *MessagingService {
+	t.Helper()
+
+	srvc := NewService(Config{
+		Broker:       &fakeBroker{messages: make(chan []byte, 100)},
+		QueueSize:    64,
+		EventLogSize: 100,
+	})
+
+	go srvc.Run()
+
+	return srvc
+}
+
+func connectTestClient(srvc *MessagingService, userId, sessionId uuid.UUID, servers, channels []uuid.UUID) *Client {
+	client := &Client{
+		ID:               userId,
+		ConnectionID:     uuid.New(),
+		SessionID:        sessionId,
+		MessagingChannel: make(chan any, srvc.QueueSize),
+	}
+
+	srvc.Connect <- &NewClient{
+		ClientObj: client,
+		Servers:   &servers,
+		Channels:  &channels,
+	}
+
+	return client
+}
+
+func channelEvent(channelId uuid.UUID, marker string) *BroadcastMessage {
+	return &BroadcastMessage{
+		Type:      CHANNEL_EVENT,
+		ChannelId: channelId,
+		Message: map[string]any{
+			"type": CHANNEL_EVENT,
+			"data": map[string]any{"marker": marker},
+		},
+	}
+}
+
+func receiveFrame(t *testing.T, client *Client, frameType string) map[string]any {
+	t.Helper()
+
+	timeout := time.After(RECEIVE_TIMEOUT)
+	for {
+		select {
+		case message, ok := <-client.MessagingChannel:
+			if !ok {
+				t.Fatalf("client channel closed while waiting for %s", frameType)
+			}
+
+			frame, _ := message.(map[string]any)
+			if frame["type"] == frameType {
+				return frame
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", frameType)
+		}
+	}
+}
+
+func expectNoFrame(t *testing.T, srvc *MessagingService, client *Client, frameType string) {
+	t.Helper()
+
+	barrier := uuid.NewString()
+	srvc.Broadcast <- &BroadcastMessage{
+		Type:   USER_EVENT,
+		UserId: client.ID,
+		Message: map[string]any{
+			"type": USER_EVENT,
+			"data": map[string]any{"barrier": barrier},
+		},
+	}
+
+	timeout := time.After(RECEIVE_TIMEOUT)
+	for {
+		select {
+		case message, ok := <-client.MessagingChannel:
+			if !ok {
+				return
+			}
+
+			frame, _ := message.(map[string]any)
+			if frame["type"] == frameType {
+				t.Fatalf("unexpected %s frame: %v", frameType, frame)
+			}
+
+			data, _ := frame["data"].(map[string]any)
+			if frame["type"] == USER_EVENT && data["barrier"] == barrier {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for the %s barrier", frameType)
+		}
+	}
+}
+
+func TestBroadcastReachesOnlyChannelSubscribers(t *testing.T) {
+	srvc := newTestService(t)
+	channelId := uuid.New()
+
+	subscriber := connectTestClient(srvc, uuid.New(), uuid.New(), nil, []uuid.UUID{channelId})
+	outsider := connectTestClient(srvc, uuid.New(), uuid.New(), nil, []uuid.UUID{uuid.New()})
+
+	srvc.Broadcast <- channelEvent(channelId, "hello")
+
+	frame := receiveFrame(t, subscriber, CHANNEL_EVENT)
+	if _, ok := frame["seq"]; !ok {
+		t.Errorf("channel event has no seq: %v", frame)
+	}
+
+	expectNoFrame(t, srvc, outsider, CHANNEL_EVENT)
+}
+
+func TestSubscribeAndUnsubscribe(t *testing.T) {
+	srvc := newTestService(t)
+	serverId, channelId := uuid.New(), uuid.New()
+
+	client := connectTestClient(srvc, uuid.New(), uuid.New(), nil, nil)
+
+	srvc.JoinChannels(client, serverId, []uuid.UUID{channelId})
+	srvc.Broadcast <- channelEvent(channelId, "joined")
+	receiveFrame(t, client, CHANNEL_EVENT)
+
+	srvc.QuitChannel(client, channelId)
+	srvc.Broadcast <- channelEvent(channelId, "left")
+	expectNoFrame(t, srvc, client, CHANNEL_EVENT)
+}
+
+func TestRevokeDisconnectsSessionClients(t *testing.T) {
+	srvc := newTestService(t)
+	channelId, sessionId := uuid.New(), uuid.New()
+
+	revoked := connectTestClient(srvc, uuid.New(), sessionId, nil, []uuid.UUID{channelId})
+	other := connectTestClient(srvc, uuid.New(), uuid.New(), nil, []uuid.UUID{channelId})
+
+	srvc.Broadcast <- &BroadcastMessage{Type: SESSION_REVOKED, Sessions: []uuid.UUID{sessionId}}
+
+	frame := receiveFrame(t, revoked, SESSION_REVOKED)
+	data, _ := frame["data"].(map[string]any)
+	if data["session_id"] != sessionId {
+		t.Errorf("session_id = %v, want %v", data["session_id"], sessionId)
+	}
+
+	srvc.Broadcast <- channelEvent(channelId, "after revoke")
+	receiveFrame(t, other, CHANNEL_EVENT)
+
+	for {
+		select {
+		case message, ok := <-revoked.MessagingChannel:
+			if !ok {
+				return
+			}
+
+			if frame, _ := message.(map[string]any); frame["type"] == CHANNEL_EVENT {
+				t.Fatalf("revoked client received %v", frame)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func TestTypingIsRelayedAndStoppedOnDisconnect(t *testing.T) {
+	srvc := newTestService(t)
+	channelId := uuid.New()
+
+	typist := connectTestClient(srvc, uuid.New(), uuid.New(), nil, []uuid.UUID{channelId})
+	reader := connectTestClient(srvc, uuid.New(), uuid.New(), nil, []uuid.UUID{channelId})
+
+	srvc.Broadcast <- &BroadcastMessage{Type: TYPING_START, ChannelId: channelId, SenderId: typist.ID}
+
+	frame := receiveFrame(t, reader, TYPING_START)
+	data, _ := frame["data"].(map[string]any)
+	if data["user_id"] != typist.ID {
+		t.Errorf("user_id = %v, want %v", data["user_id"], typist.ID)
+	}
+
+	expectNoFrame(t, srvc, typist, TYPING_START)
+
+	srvc.Disconnect <- typist
+	receiveFrame(t, reader, TYPING_STOP)
+}
+
+func TestConcurrentOperations(t *testing.T) {
+	srvc := newTestService(t)
+	serverId, channelId := uuid.New(), uuid.New()
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < 50; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+
+			sessionId := uuid.New()
+			client := connectTestClient(srvc, uuid.New(), sessionId, []uuid.UUID{serverId}, nil)
+
+			srvc.JoinChannels(client, serverId, []uuid.UUID{channelId})
+			srvc.Broadcast <- channelEvent(channelId, "concurrent")
+			srvc.Broadcast <- &BroadcastMessage{Type: TYPING_START, ChannelId: channelId, SenderId: client.ID}
+			srvc.Activity <- client
+			srvc.QuitServer(client, serverId, []uuid.UUID{channelId})
+
+			if idx%2 == 0 {
+				srvc.Broadcast <- &BroadcastMessage{Type: SESSION_REVOKED, Sessions: []uuid.UUID{sessionId}}
+			} else {
+				srvc.Disconnect <- client
+			}
+		}(idx)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-srvc.Presence:
+			case <-done:
+				return
+			}
+		}
+	}()
+	defer close(done)
+
+	wg.Wait()
+
+	probe := connectTestClient(srvc, uuid.New(), uuid.New(), nil, []uuid.UUID{channelId})
+	srvc.Broadcast <- channelEvent(channelId, "probe")
+	receiveFrame(t, probe, CHANNEL_EVENT)
+
+	if stats := srvc.Stats(); stats.EvictedClients != 0 {
+		t.Errorf("evicted %d clients under the drop policy", stats.EvictedClients)
+	}
+}
+
+func TestAccessChangeChecksChannelSubscribers(t *testing.T) {
+	srvc := newTestService(t)
+	serverId, channelId := uuid.New(), uuid.New()
+
+	subscriber := connectTestClient(srvc, uuid.New(), uuid.New(), []uuid.UUID{serverId}, []uuid.UUID{channelId})
+	connectTestClient(srvc, uuid.New(), uuid.New(), []uuid.UUID{serverId}, nil)
+
+	srvc.Broadcast <- &BroadcastMessage{Type: ACCESS_CHANGED, ServerId: serverId, Channels: []uuid.UUID{channelId}}
+
+	select {
+	case change := <-srvc.MembershipChanges:
+		check, ok := change.(*AccessCheck)
+		if !ok {
+			t.Fatalf("got %T, want an access check", change)
+		}
+
+		if check.ChannelId != channelId || len(check.Clients) != 1 || check.Clients[0] != subscriber {
+			t.Errorf("access check = %+v, want only the channel subscriber", check)
+		}
+	case <-time.After(RECEIVE_TIMEOUT):
+		t.Fatal("timed out waiting for the access check")
+	}
+}
+
+func TestSubscribeIgnoresDisconnectedClients(t *testing.T) {
+	srvc := newTestService(t)
+	serverId, channelId := uuid.New(), uuid.New()
+
+	client := connectTestClient(srvc, uuid.New(), uuid.New(), nil, nil)
+	srvc.Disconnect <- client
+	srvc.JoinChannels(client, serverId, []uuid.UUID{channelId})
+
+	probe := connectTestClient(srvc, uuid.New(), uuid.New(), nil, []uuid.UUID{channelId})
+	srvc.Broadcast <- channelEvent(channelId, "probe")
+	receiveFrame(t, probe, CHANNEL_EVENT)
+
+	select {
+	case message := <-client.MessagingChannel:
+		t.Errorf("disconnected client received %v", message)
+	default:
+	}
+}
+
+func TestEventsCarryTheBrokerSeqAndReplayAfterIt(t *testing.T) {
+	srvc := newTestService(t)
+	channelId := uuid.New()
+
+	reader := connectTestClient(srvc, uuid.New(), uuid.New(), nil, []uuid.UUID{channelId})
+
+	srvc.Broadcast <- channelEvent(channelId, "first")
+	first := receiveFrame(t, reader, CHANNEL_EVENT)
+	srvc.Broadcast <- channelEvent(channelId, "second")
+	second := receiveFrame(t, reader, CHANNEL_EVENT)
+
+	if first["seq"] != uint64(1) || second["seq"] != uint64(2) {
+		t.Fatalf("seq = %v, %v, want 1, 2", first["seq"], second["seq"])
+	}
+
+	channels := []uuid.UUID{channelId}
+	resumed := &Client{
+		ID:               uuid.New(),
+		ConnectionID:     uuid.New(),
+		SessionID:        uuid.New(),
+		MessagingChannel: make(chan any, srvc.QueueSize),
+	}
+
+	srvc.Connect <- &NewClient{
+		ClientObj: resumed,
+		Servers:   &[]uuid.UUID{},
+		Channels:  &channels,
+		IsResumed: true,
+		LastSeq:   1,
+	}
+
+	replayed := receiveFrame(t, resumed, CHANNEL_EVENT)
+	if replayed["seq"] != uint64(2) {
+		t.Errorf("replayed seq = %v, want 2", replayed["seq"])
+	}
+}
+
+func TestPublicChannelCreationChecksServerClients(t *testing.T) {
+	srvc := newTestService(t)
+	serverId, channelId := uuid.New(), uuid.New()
+
+	creator := connectTestClient(srvc, uuid.New(), uuid.New(), []uuid.UUID{serverId}, nil)
+	member := connectTestClient(srvc, uuid.New(), uuid.New(), []uuid.UUID{serverId}, nil)
+
+	srvc.Broadcast <- &BroadcastMessage{
+		Type:      DOMAIN_EVENT,
+		Event:     CHANNEL_CREATED,
+		ServerId:  serverId,
+		ChannelId: channelId,
+		UserId:    creator.ID,
+		Message:   map[string]any{"type": CHANNEL_CREATED},
+	}
+
+	select {
+	case change := <-srvc.MembershipChanges:
+		check, ok := change.(*AccessCheck)
+		if !ok {
+			t.Fatalf("got %T, want an access check", change)
+		}
+
+		if check.ChannelId != channelId || !slices.Contains(check.Clients, member) {
+			t.Errorf("access check = %+v, want one covering the other server member", check)
+		}
+	case <-time.After(RECEIVE_TIMEOUT):
+		t.Fatal("timed out waiting for the access check")
+	}
+}
@@ -1,35 +1,123 @@
 package msgsrvc
 
 import (
+	"sync"
+	"time"
+
+	"github.com/critch-app/critch-backend/internal/ports"
 	"github.com/google/uuid"
 )
 
 type Client struct {
 	ID               uuid.UUID `json:"id"`
+	ConnectionID     uuid.UUID `json:"connection_id"`
+	SessionID        uuid.UUID `json:"session_id"`
 	MessagingChannel chan any
+
+	needsResync bool
+	closeOnce   sync.Once
+}
+
+func (client *Client) Close() {
+	client.closeOnce.Do(func() {
+		close(client.MessagingChannel)
+	})
+}
+
+type Config struct {
+	Broker           ports.Broker
+	QueueSize        int
+	SlowClientPolicy string
+	EventLogSize     int
+}
+
+type Stats struct {
+	DroppedMessages int64 `json:"dropped_messages"`
+	EvictedClients  int64 `json:"evicted_clients"`
+	ResyncedClients int64 `json:"resynced_clients"`
 }
 
 type NewClient struct {
 	ClientObj *Client
 	Servers   *[]uuid.UUID
 	Channels  *[]uuid.UUID
+	IsResumed bool
+	LastSeq   uint64
 }
 
-type BroadcastMessage struct {
+type loggedEvent struct {
+	Seq       uint64
 	Type      string
+	ServerId  uuid.UUID
 	ChannelId uuid.UUID
+	UserId    uuid.UUID
+	Users     []uuid.UUID
+	Event     map[string]any
+}
+
+type Subscription struct {
+	ClientObj *Client
 	ServerId  uuid.UUID
-	Message   any
+	Channels  []uuid.UUID
+}
+
+type BroadcastMessage struct {
+	Type         string      `json:"type"`
+	Event        string      `json:"event,omitempty"`
+	Seq          uint64      `json:"seq,omitempty"`
+	ChannelId    uuid.UUID   `json:"channel_id"`
+	ServerId     uuid.UUID   `json:"server_id"`
+	UserId       uuid.UUID   `json:"user_id"`
+	SenderId     uuid.UUID   `json:"sender_id"`
+	ConnectionId uuid.UUID   `json:"connection_id"`
+	Channels     []uuid.UUID `json:"channels,omitempty"`
+	Users        []uuid.UUID `json:"users,omitempty"`
+	Servers      []uuid.UUID `json:"servers,omitempty"`
+	Sessions     []uuid.UUID `json:"sessions,omitempty"`
+	Scopes       []uuid.UUID `json:"scopes,omitempty"`
+	Message      any         `json:"message"`
 }
 
 type IncomingMessage struct {
 	ServerId   uuid.UUID `json:"server_id"`
 	ChannelId  uuid.UUID `json:"channel_id" binding:"required"`
 	SenderId   uuid.UUID `json:"sender_id"`
+	ParentId   uuid.UUID `json:"parent_id"`
+	Nonce      string    `json:"nonce"`
 	Content    string    `json:"content" binding:"required"`
 	Attachment string    `json:"attachment"`
 }
 
+type MarkRead struct {
+	ServerId  uuid.UUID `json:"server_id"`
+	ChannelId uuid.UUID `json:"channel_id" binding:"required"`
+	MessageId uuid.UUID `json:"message_id" binding:"required"`
+	SenderId  uuid.UUID `json:"sender_id"`
+}
+
+type Typing struct {
+	Type      string    `json:"-"`
+	ServerId  uuid.UUID `json:"server_id"`
+	ChannelId uuid.UUID `json:"channel_id" binding:"required"`
+	SenderId  uuid.UUID `json:"sender_id"`
+}
+
+type PresenceUpdate struct {
+	UserId   uuid.UUID
+	Status   string
+	LastSeen time.Time
+}
+
+type PresenceHeartbeat struct {
+	Users []PresenceUpdate
+}
+
+type AccessCheck struct {
+	ServerId  uuid.UUID
+	ChannelId uuid.UUID
+	Clients   []*Client
+}
+
 type JoinChannel struct {
 	ServerId uuid.UUID   `json:"server_id" binding:"required"`
 	SenderId uuid.UUID   `json:"sender_id"`
@@ -47,26 +135,64 @@ type QuitServer struct {
 	SenderId uuid.UUID `json:"sender_id"`
 }
 
-type RemoveChannel struct {
-	SenderId  uuid.UUID `json:"sender_id"`
-	ChannelId uuid.UUID `json:"channel_id" binding:"required"`
-	ServerId  uuid.UUID `json:"server_id" binding:"required"`
-}
+const (
+	ERROR              = "error"
+	HELLO              = "hello"
+	RESPONSE           = "response"
+	NOTIFICATION       = "notification"
+	JOIN_CHANNEL       = "join_channel"
+	QUIT_CHANNEL       = "quit_channel"
+	QUIT_SERVER        = "quit_server"
+	MESSAGE            = "message"
+	ACK                = "ack"
+	MESSAGE_UPDATED    = "message_updated"
+	MESSAGE_DELETED    = "message_deleted"
+	MENTION            = "mention"
+	CHANNEL_EVENT      = "channel_event"
+	USER_EVENT         = "user_event"
+	DOMAIN_EVENT       = "domain_event"
+	MARK_READ          = "mark_read"
+	TYPING_START       = "typing_start"
+	TYPING_STOP        = "typing_stop"
+	LOGGED_IN          = "logged_in"
+	LOGGED_OUT         = "logged_out"
+	SESSION_REVOKED    = "session_revoked"
+	MEMBER_JOINED      = "member_joined"
+	MEMBER_REMOVED     = "member_removed"
+	SERVER_UPDATED     = "server_updated"
+	SERVER_DELETED     = "server_deleted"
+	CHANNEL_CREATED    = "channel_created"
+	CHANNEL_UPDATED    = "channel_updated"
+	CHANNEL_DELETED    = "channel_deleted"
+	REACTION_ADDED     = "reaction_added"
+	REACTION_REMOVED   = "reaction_removed"
+	READ_STATE_UPDATED = "read_state_updated"
+	PRESENCE_UPDATED   = "presence_updated"
+	MEMBERSHIP_CHANGED = "membership_changed"
+	ACCESS_CHANGED     = "access_changed"
+	STREAM_FRAME       = "stream_frame"
+	RESYNC_REQUIRED    = "resync_required"
+)
 
-type RemoveServer struct {
-	ServerId uuid.UUID `json:"server_id" binding:"required"`
-	SenderId uuid.UUID `json:"sender_id"`
-}
+const (
+	DROP_POLICY       = "drop"
+	DISCONNECT_POLICY = "disconnect"
+	RESYNC_POLICY     = "resync"
+)
+
+const (
+	DEFAULT_QUEUE_SIZE     = 256
+	DEFAULT_EVENT_LOG_SIZE = 1000
+)
+
+const PUBLISH_TIMEOUT = 2 * time.Second
+
+const (
+	TYPING_TIMEOUT        = 8 * time.Second
+	TYPING_CHECK_INTERVAL = time.Second
+)
 
 const (
-	ERROR          = "error"
-	NOTIFICATION   = "notification"
-	JOIN_CHANNEL   = "join_channel"
-	QUIT_CHANNEL   = "quit_channel"
-	QUIT_SERVER    = "quit_server"
-	REMOVE_CHANNEL = "remove_channel"
-	REMOVE_SERVER  = "remove_server"
-	MESSAGE        = "message"
-	LOGGED_IN      = "logged_in"
-	LOGGED_OUT     = "logged_out"
+	IDLE_TIMEOUT            = 5 * time.Minute
+	PRESENCE_CHECK_INTERVAL = 30 * time.Second
 )
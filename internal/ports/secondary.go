@@ -1,10 +1,18 @@
 package ports
 
 import (
+	"errors"
+	"time"
+
 	"github.com/critch-app/critch-backend/internal/application/core/entities"
 	"github.com/google/uuid"
 )
 
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+)
+
 type DB interface {
 	Migrate(models ...any) error
 
@@ -13,37 +21,90 @@ type DB interface {
 	GetUserByEmail(email string) (*entities.User, error)
 	GetAllUsers(offset, limit int) (*[]entities.User, error)
 	UpdateUser(user *entities.User) error
+	UpdateUserPresence(userId uuid.UUID, status string, lastSeen time.Time) error
+	SetUserPresence(presence *entities.UserPresence) error
+	RemoveUserPresence(instanceId, userId uuid.UUID) error
+	GetUserPresences(userId uuid.UUID, since time.Time) (*[]entities.UserPresence, error)
+	RefreshUserPresences(instanceId uuid.UUID, presences *[]entities.UserPresence, heartbeatAt, since time.Time) (*[]uuid.UUID, error)
 	GetUserServers(userId uuid.UUID, offset, limit int) (*[]entities.Server, error)
 	GetUserDMChannels(userId uuid.UUID, offset, limit int) (*[]entities.DMChannel, error)
-	GetUserChannelIds(userId uuid.UUID) (*[]uuid.UUID, error)
+	GetUserChannelIds(userId uuid.UUID, isServerChannel bool) (*[]uuid.UUID, error)
 	GetUserServerIds(userId uuid.UUID) (*[]uuid.UUID, error)
 	DeleteUser(id uuid.UUID) error
 
 	CreateServer(server *entities.Server) error
 	GetServer(id uuid.UUID) (*entities.Server, error)
 	GetServerByName(name string) (*entities.Server, error)
-	GetAllServers(offset, limit int) (*[]entities.Server, error)
+	GetAllServers(userId uuid.UUID, offset, limit int) (*[]entities.Server, error)
 	UpdateServer(server *entities.Server) error
 	GetServerMembers(serverId uuid.UUID, offset, limit int) (*[]entities.User, error)
-	AddServerMember(member *entities.ServerMember) error
+	AddServerMember(member *entities.ServerMember, roleId *uuid.UUID) (*[]uuid.UUID, error)
 	RemoveServerMember(serverId, userId uuid.UUID) error
 	GetServerChannels(serverId, userId uuid.UUID, offset, limit int) (*[]entities.ServerChannel, error)
+	GetServerChannelIds(serverId uuid.UUID, mode string) (*[]uuid.UUID, error)
 	DeleteServer(id uuid.UUID) error
 
 	CreateChannel(channel any) error
 	GetChannel(channel any) error
-	GetAllChannels(channels any, offset, limit int) error
+	GetAllChannels(channels any, userId uuid.UUID, offset, limit int) error
 	UpdateChannel(channel any) error
 	GetChannelMembers(channelMembers any, channelId uuid.UUID, offset, limit int) error
+	GetChannelMember(channelMember any) error
 	AddChannelMember(channelMember any) error
 	RemoveChannelMember(channelMember any) error
-	GetChannelMessages(channelMessages any, channelId uuid.UUID, offset, limit int) error
+	GetChannelMessages(channelMessages any, channelId uuid.UUID, cursor *entities.MessageCursor, limit int) error
+	GetChannelOverrides(channelId uuid.UUID) (*[]entities.ChannelPermissionOverride, error)
+	SetChannelOverride(override *entities.ChannelPermissionOverride) error
+	RemoveChannelOverride(override *entities.ChannelPermissionOverride) error
 	DeleteChannel(channel any) error
 
-	CreateMessage(msg any) error
+	CreateMessage(msg any, nonceSince time.Time) error
 	GetMessage(msg any) error
 	UpdateMessage(msg any) error
 	DeleteMessage(msg any) error
+	GetMessageReplies(replies any, parentId uuid.UUID, offset, limit int) error
+	GetMessageRevisions(revisions any, messageId uuid.UUID, offset, limit int) error
+	FindMessageByNonce(msg any, senderId uuid.UUID, nonce string, since time.Time) (bool, error)
+	SearchMessages(search *entities.MessageSearch) (*[]entities.MessageSearchResult, error)
+
+	AddReaction(reaction any) error
+	RemoveReaction(reaction any) error
+	GetReactionCounts(reaction any, messageIds []uuid.UUID) (*[]entities.ReactionCount, error)
+	GetOnlineChannelMemberIds(channelId uuid.UUID, isServerChannel bool, since time.Time) ([]uuid.UUID, error)
+	GetMentionedUserIds(msg any) ([]uuid.UUID, error)
+
+	SetReadState(readState *entities.ChannelReadState) (bool, error)
+	GetReadState(readState *entities.ChannelReadState) error
+	GetUnreadCounts(userId uuid.UUID, channelIds []uuid.UUID, isServerChannel bool) (*[]entities.UnreadCount, error)
+	GetServerUnreadCounts(userId uuid.UUID, serverIds []uuid.UUID) (*[]entities.UnreadCount, error)
 
 	GetServerMemberRole(serverId, userId uuid.UUID) (string, error)
+
+	CreateRole(role *entities.ServerRole) error
+	GetRole(id uuid.UUID) (*entities.ServerRole, error)
+	GetServerRoles(serverId uuid.UUID, offset, limit int) (*[]entities.ServerRole, error)
+	UpdateRole(role *entities.ServerRole) error
+	DeleteRole(id uuid.UUID) error
+	AddMemberRole(memberRole *entities.ServerMemberRole) error
+	RemoveMemberRole(memberRole *entities.ServerMemberRole) error
+	GetServerMemberRoles(serverId, userId uuid.UUID) (*[]entities.ServerRole, error)
+
+	CreateInvite(invite *entities.ServerInvite) error
+	GetInvite(code string) (*entities.ServerInvite, error)
+	GetServerInvites(serverId uuid.UUID, offset, limit int) (*[]entities.ServerInvite, error)
+	RedeemInvite(code string, member *entities.ServerMember) (*entities.ServerInvite, *[]uuid.UUID, error)
+	DeleteInvite(code string) error
+
+	CreateSession(session *entities.Session) error
+	GetSession(id uuid.UUID) (*entities.Session, error)
+	RotateSession(session *entities.Session, refreshTokenHash string) (bool, error)
+	RevokeSession(id uuid.UUID) error
+	RevokeUserSessions(userId uuid.UUID) (*[]uuid.UUID, error)
+}
+
+type Broker interface {
+	NextSeq() (uint64, error)
+	Publish(payload []byte) error
+	Messages() <-chan []byte
+	Close() error
 }